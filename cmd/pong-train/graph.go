@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/dstoiko/go-pong-wasm/pong"
+	"os"
+	"path/filepath"
+)
+
+// runGraph plays the network against an opponent and writes a snapshot of the neuron graph every few frames
+func runGraph(args []string) error {
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	seed := flags.Int64("seed", 1, "seed of the world, the network and the opponent")
+	name := flags.String("opponent", "medium", "opponent: idle, script, tracker, easy, medium, hard or perfect")
+	perception := flags.String("perception", string(pong.PixelPerception), "network input: pixels or observation")
+	frames := flags.Int("frames", 600, "number of frames to play")
+	every := flags.Int("every", 10, "number of frames between two snapshots")
+	format := flags.String("format", "dot", "snapshot format: dot or graphml")
+	dir := flags.String("out", "graphs", "directory of the snapshots")
+	config := networkFlags(flags)
+	flags.Parse(args)
+
+	if *every < 1 {
+		return fmt.Errorf("invalid snapshot interval: %d", *every)
+	}
+	if *format != "dot" && *format != "graphml" {
+		return fmt.Errorf("invalid format: %s", *format)
+	}
+	p := pong.Perception(*perception)
+	if p != pong.PixelPerception && p != pong.ObservationPerception {
+		return fmt.Errorf("invalid perception: %s", *perception)
+	}
+	player2, err := opponent(*name, *seed+1)
+	if err != nil {
+		return err
+	}
+	player1, err := pong.NewNetworkController(*seed, p, *config)
+	if err != nil {
+		return fmt.Errorf("invalid network: %w", err)
+	}
+	world, err := pong.NewWorld(arenaWidth, arenaHeight, pong.DefaultRules(), *seed)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(*dir, 0755)
+	if err != nil {
+		return err
+	}
+	for frame := range *frames + 1 {
+		if frame%*every == 0 {
+			err = snapshot(&player1.Network, filepath.Join(*dir, fmt.Sprintf("frame-%06d.%s", frame, *format)), *format)
+			if err != nil {
+				return err
+			}
+		}
+		if frame < *frames {
+			world.Tick(player1, player2, p == pong.PixelPerception)
+		}
+	}
+	return nil
+}
+
+// snapshot writes the neuron graph of the network to a file
+func snapshot(network *pong.Network, name, format string) error {
+	output, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	if format == "graphml" {
+		return network.WriteGraphML(output)
+	}
+	return network.WriteDOT(output)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/dstoiko/go-pong-wasm/pong"
+	"log"
+	"os"
+	"strings"
+)
+
+const (
+	arenaWidth  = 800
+	arenaHeight = 600
+)
+
+// Report is the evaluation of the network player over the episodes
+type Report struct {
+	Seed       int64           `json:"seed"`
+	Opponent   string          `json:"opponent"`
+	Perception pong.Perception `json:"perception"`
+	Episodes   int             `json:"episodes"`
+	Ticks      int             `json:"ticks"`
+	Hits       int             `json:"hits"`
+	Misses     int             `json:"misses"`
+	// HitRate is the fraction of the incoming balls the network returned
+	HitRate      float64 `json:"hit_rate"`
+	MeanRally    float64 `json:"mean_rally"`
+	LongestRally int     `json:"longest_rally"`
+	// PointsPerMinute are the points won by the network per minute of play
+	PointsPerMinute float64 `json:"points_per_minute"`
+	// OpponentPointsPerMinute are the points won by the opponent per minute of play
+	OpponentPointsPerMinute float64 `json:"opponent_points_per_minute"`
+	// Stack is the number of frames stacked for the network
+	Stack      int  `json:"stack"`
+	Difference bool `json:"difference"`
+	// Learning is true when the network learned from its hits and misses
+	Learning bool `json:"learning"`
+	// Window is the number of episodes of each point of the curve
+	Window int `json:"window"`
+	// Curve is the hit rate of each window of episodes
+	Curve []float64 `json:"curve"`
+	// window are the hits and the misses of the current window
+	window [2]int
+}
+
+// Add adds an episode to the report
+func (r *Report) Add(episode pong.Episode) {
+	r.Episodes++
+	r.Ticks += episode.Ticks
+	r.Hits += episode.Player1.Hits
+	r.Misses += episode.Player2.Points
+	r.window[0] += episode.Player1.Hits
+	r.window[1] += episode.Player2.Points
+	if r.Episodes%r.Window == 0 {
+		r.Curve = append(r.Curve, hitRate(r.window[0], r.window[1]))
+		r.window = [2]int{}
+	}
+	r.MeanRally += float64(episode.Rally)
+	r.LongestRally = max(r.LongestRally, episode.Rally)
+	r.PointsPerMinute += float64(episode.Player1.Points)
+	r.OpponentPointsPerMinute += float64(episode.Player2.Points)
+}
+
+// Finish turns the totals into rates
+func (r *Report) Finish() {
+	r.HitRate = hitRate(r.Hits, r.Misses)
+	if r.Episodes > 0 {
+		r.MeanRally /= float64(r.Episodes)
+	}
+	if r.Ticks > 0 {
+		minutes := float64(r.Ticks) / pong.TPS / 60
+		r.PointsPerMinute /= minutes
+		r.OpponentPointsPerMinute /= minutes
+	}
+}
+
+// networkFlags adds the flags of the shape of the network to a flag set
+func networkFlags(flags *flag.FlagSet) *pong.NetworkConfig {
+	config := pong.DefaultNetworkConfig()
+	flags.IntVar(&config.Inputs, "inputs", config.Inputs, "number of input neurons of the network")
+	flags.IntVar(&config.Actions, "actions", config.Actions, "number of action neurons of the network")
+	flags.IntVar(&config.Width, "width", config.Width, "number of connections of each neuron")
+	flags.IntVar(&config.Embedding, "embedding", config.Embedding, "size of the embedding of each neuron")
+	return &config
+}
+
+// hitRate is the fraction of the incoming balls that were returned
+func hitRate(hits, misses int) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// opponent creates the controller of player2
+func opponent(name string, seed int64) (pong.Controller, error) {
+	switch name {
+	case "idle":
+		return &pong.Script{}, nil
+	case "script":
+		return pong.NewSweepScript(30), nil
+	case "tracker":
+		return pong.Tracker{}, nil
+	}
+	for _, difficulty := range pong.Difficulties {
+		if strings.EqualFold(name, difficulty.String()) {
+			return pong.NewAI(difficulty, seed), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown opponent: %s", name)
+}
+
+func main() {
+	err := run(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run runs a subcommand or evaluates the network player, the files are flushed and closed before it returns
+func run(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "graph":
+			return runGraph(args[1:])
+		case "selfplay":
+			return runSelfPlay(args[1:])
+		}
+	}
+	return runEvaluation(args)
+}
+
+// runEvaluation plays the network player against an opponent and prints a report of the episodes as JSON
+func runEvaluation(args []string) (err error) {
+	flags := flag.NewFlagSet("pong-train", flag.ExitOnError)
+	episodes := flags.Int("episodes", 10, "number of episodes, an episode is one point")
+	seed := flags.Int64("seed", 1, "seed of the world, the network and the opponent")
+	name := flags.String("opponent", "medium", "opponent: idle, script, tracker, easy, medium, hard or perfect")
+	perception := flags.String("perception", string(pong.PixelPerception), "network input: pixels or observation")
+	ticks := flags.Int("ticks", 2*60*pong.TPS, "maximum number of ticks of an episode")
+	rulesFile := flags.String("rules", "", "load the match rules from a JSON file")
+	telemetryFile := flags.String("telemetry", "", "write the telemetry of the network and the world to a JSONL file")
+	learn := flags.Bool("learn", false, "reward the network for its hits and misses")
+	frames := flags.Int("frames", pong.LearningFrames, "number of frames a decision stays eligible for a reward")
+	decay := flags.Float64("decay", pong.LearningDecay, "decay of the eligibility of a decision each frame")
+	rate := flags.Float64("rate", pong.LearningRate, "walk counts gained by the most eligible connection for a reward of 1")
+	readoutRate := flags.Float64("readout-rate", pong.LearningReadoutRate, "share of the newest perception the readout of its side gains for a reward of 1")
+	window := flags.Int("window", 10, "number of episodes of each point of the hit rate curve")
+	config := networkFlags(flags)
+	networkFile := flags.String("network", "", "load the network from a file if it exists and save it there after the episodes")
+	stack := flags.Int("stack", 1, "number of frames stacked for the network")
+	difference := flags.Bool("difference", false, "stack the differences between consecutive frames instead of the older frames")
+	deadband := flags.Float64("deadband", 0, "how close to even the vote of the network has to be for the paddle to stay")
+	analog := flags.Bool("analog", false, "push the paddle harder the more one side wins the vote of the network")
+	flags.Parse(args)
+
+	rules := pong.DefaultRules()
+	if *rulesFile != "" {
+		input, err := os.Open(*rulesFile)
+		if err != nil {
+			return err
+		}
+		rules, err = pong.LoadRules(input)
+		input.Close()
+		if err != nil {
+			return fmt.Errorf("invalid rules %s: %w", *rulesFile, err)
+		}
+	}
+	p := pong.Perception(*perception)
+	if p != pong.PixelPerception && p != pong.ObservationPerception {
+		return fmt.Errorf("invalid perception: %s", *perception)
+	}
+	player2, err := opponent(*name, *seed+1)
+	if err != nil {
+		return err
+	}
+	if *window < 1 {
+		return fmt.Errorf("invalid window: %d", *window)
+	}
+	player1, err := pong.NewNetworkController(*seed, p, *config)
+	if err != nil {
+		return fmt.Errorf("invalid network: %w", err)
+	}
+	if *networkFile != "" {
+		network, err := loadNetwork(*networkFile)
+		if err == nil {
+			player1.Network = network
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("invalid network %s: %w", *networkFile, err)
+		}
+	}
+	player1.Stack = pong.FrameStack{Frames: *stack, Difference: *difference}
+	player1.Deadband, player1.Analog = *deadband, *analog
+	if *learn {
+		player1.Eligibility = pong.NewEligibility(*frames, *decay, *rate, *readoutRate)
+	}
+
+	world, err := pong.NewWorld(arenaWidth, arenaHeight, rules, *seed)
+	if err != nil {
+		return err
+	}
+	if *telemetryFile != "" {
+		var telemetry *pong.JSONL
+		var closeTelemetry func() error
+		telemetry, closeTelemetry, err = openTelemetry(*telemetryFile)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			err = errors.Join(err, closeTelemetry())
+		}()
+		player1.Network.Telemetry, world.Telemetry = telemetry, telemetry
+	}
+	report := Report{
+		Seed:       *seed,
+		Opponent:   *name,
+		Perception: p,
+		Stack:      max(*stack, 1),
+		Difference: *difference,
+		Learning:   *learn,
+		Window:     *window,
+		Curve:      []float64{},
+	}
+	for range *episodes {
+		report.Add(world.Play(player1, player2, *ticks, p == pong.PixelPerception))
+	}
+	report.Finish()
+	if *networkFile != "" {
+		err = saveNetwork(*networkFile, &player1.Network)
+		if err != nil {
+			return err
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// openTelemetry creates a JSONL telemetry file, close flushes and closes it
+// and returns the first error of writing the telemetry
+func openTelemetry(name string) (*pong.JSONL, func() error, error) {
+	output, err := os.Create(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	buffer := bufio.NewWriter(output)
+	telemetry := pong.NewJSONL(buffer)
+	return telemetry, func() error {
+		return errors.Join(telemetry.Err, buffer.Flush(), output.Close())
+	}, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/dstoiko/go-pong-wasm/pong"
+	"os"
+)
+
+// loadNetwork reads a network from a file
+func loadNetwork(name string) (pong.Network, error) {
+	input, err := os.Open(name)
+	if err != nil {
+		return pong.Network{}, err
+	}
+	defer input.Close()
+	return pong.ReadNetwork(input)
+}
+
+// saveNetwork writes a network to a file
+func saveNetwork(name string, network *pong.Network) error {
+	output, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = network.Write(output)
+	if err != nil {
+		output.Close()
+		return err
+	}
+	return output.Close()
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/dstoiko/go-pong-wasm/pong"
+	"log"
+	"os"
+)
+
+// runSelfPlay plays matches between every pair of saved networks and rates them in an Elo table
+func runSelfPlay(args []string) error {
+	flags := flag.NewFlagSet("selfplay", flag.ExitOnError)
+	seed := flags.Int64("seed", 1, "seed of the world of the first match, the next matches use the next seeds")
+	perception := flags.String("perception", string(pong.PixelPerception), "network input: pixels or observation")
+	games := flags.Int("games", 2, "number of matches of each pair of networks, the networks switch sides after each match")
+	points := flags.Int("points", 5, "score a network needs to win a match")
+	ticks := flags.Int("ticks", 5*60*pong.TPS, "maximum number of ticks of a match, the leader wins when they run out")
+	eloFile := flags.String("elo", "elo.json", "Elo table that is updated with the results")
+	flags.Parse(args)
+
+	names := flags.Args()
+	if len(names) < 2 {
+		return errors.New("usage: pong-train selfplay [flags] network1 network2 ...")
+	}
+	p := pong.Perception(*perception)
+	if p != pong.PixelPerception && p != pong.ObservationPerception {
+		return fmt.Errorf("invalid perception: %s", *perception)
+	}
+	for _, name := range names {
+		_, err := loadNetwork(name)
+		if err != nil {
+			return fmt.Errorf("invalid network %s: %w", name, err)
+		}
+	}
+	table, err := readElo(*eloFile)
+	if err != nil {
+		return fmt.Errorf("invalid Elo table %s: %w", *eloFile, err)
+	}
+
+	rules := pong.DefaultRules()
+	rules.MaxScore = *points
+	err = rules.Validate()
+	if err != nil {
+		return err
+	}
+	match := 0
+	for i := range names {
+		for ii := i + 1; ii < len(names); ii++ {
+			for game := range *games {
+				a, err := newPlayer(names[i], p)
+				if err != nil {
+					return err
+				}
+				b, err := newPlayer(names[ii], p)
+				if err != nil {
+					return err
+				}
+				world, err := pong.NewWorld(arenaWidth, arenaHeight, rules, *seed+int64(match))
+				if err != nil {
+					return err
+				}
+				render := p == pong.PixelPerception
+				score := .5
+				if game%2 == 0 {
+					switch world.Match(a, b, *ticks, render) {
+					case 1:
+						score = 1
+					case 2:
+						score = 0
+					}
+				} else {
+					switch world.Match(b, a, *ticks, render) {
+					case 1:
+						score = 0
+					case 2:
+						score = 1
+					}
+				}
+				table.Update(names[i], names[ii], score)
+				log.Printf("%s vs %s: %g-%g", names[i], names[ii], score, 1-score)
+				match++
+			}
+		}
+	}
+
+	err = writeElo(*eloFile, table)
+	if err != nil {
+		return err
+	}
+	return writeRanking(table)
+}
+
+// newPlayer creates a network controller with a saved network, every match starts from the saved network
+func newPlayer(name string, perception pong.Perception) (*pong.NetworkController, error) {
+	network, err := loadNetwork(name)
+	if err != nil {
+		return nil, err
+	}
+	return &pong.NetworkController{
+		Network:    network,
+		Perception: perception,
+	}, nil
+}
+
+// readElo reads an Elo table, a missing file is an empty table
+func readElo(name string) (*pong.EloTable, error) {
+	input, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return pong.NewEloTable(), nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+	return pong.ReadEloTable(input)
+}
+
+// writeElo writes an Elo table
+func writeElo(name string, table *pong.EloTable) error {
+	output, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = table.Write(output)
+	if err != nil {
+		output.Close()
+		return err
+	}
+	return output.Close()
+}
+
+// writeRanking prints the ratings from the highest to the lowest as JSON
+func writeRanking(table *pong.EloTable) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(table.Ranking())
+}
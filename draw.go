@@ -0,0 +1,113 @@
+package main
+
+import (
+	"github.com/dstoiko/go-pong-wasm/pong"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/hajimehoshi/ebiten/text"
+	"golang.org/x/image/font"
+	"strconv"
+)
+
+// Keyboard maps a pair of keys to paddle inputs
+type Keyboard struct {
+	Up      ebiten.Key
+	Down    ebiten.Key
+	pressed keysPressed
+}
+
+type keysPressed struct {
+	up   bool
+	down bool
+}
+
+// Update reads the keys and returns the paddle input for this tick
+func (k *Keyboard) Update() pong.Input {
+	if inpututil.IsKeyJustPressed(k.Up) {
+		k.pressed.down = false
+		k.pressed.up = true
+	} else if inpututil.IsKeyJustReleased(k.Up) || !ebiten.IsKeyPressed(k.Up) {
+		k.pressed.up = false
+	}
+	if inpututil.IsKeyJustPressed(k.Down) {
+		k.pressed.up = false
+		k.pressed.down = true
+	} else if inpututil.IsKeyJustReleased(k.Down) || !ebiten.IsKeyPressed(k.Down) {
+		k.pressed.down = false
+	}
+	return pong.Input{
+		Up:   k.pressed.up,
+		Down: k.pressed.down,
+	}
+}
+
+// Act implements pong.Controller with the keys
+func (k *Keyboard) Act(o pong.Observation) pong.Input {
+	return k.Update()
+}
+
+// BallSprite draws a ball
+type BallSprite struct {
+	Img *ebiten.Image
+}
+
+// NewBallSprite creates a sprite for the ball
+func NewBallSprite(b *pong.Ball) *BallSprite {
+	img, _ := ebiten.NewImage(int(b.Radius*2), int(b.Radius*2), ebiten.FilterDefault)
+	return &BallSprite{Img: img}
+}
+
+// Draw draws the ball on the screen
+func (s *BallSprite) Draw(screen *ebiten.Image, b *pong.Ball) {
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Translate(float64(b.X-b.Radius), float64(b.Y-b.Radius))
+	// TODO: set pixels for round effect
+	s.Img.Fill(b.Color)
+	screen.DrawImage(s.Img, opts)
+}
+
+// PaddleSprite draws a paddle and its score
+type PaddleSprite struct {
+	Img          *ebiten.Image
+	scorePrinted scorePrinted
+}
+
+type scorePrinted struct {
+	score   int
+	printed bool
+	x       int
+	y       int
+}
+
+// NewPaddleSprite creates a sprite for the paddle
+func NewPaddleSprite(p *pong.Paddle) *PaddleSprite {
+	img, _ := ebiten.NewImage(p.Width, p.Height, ebiten.FilterDefault)
+	return &PaddleSprite{Img: img}
+}
+
+// Draw draws the paddle on the screen
+func (s *PaddleSprite) Draw(screen *ebiten.Image, p *pong.Paddle, scoreFont font.Face, ai bool) {
+	// draw player's paddle
+	pOpts := &ebiten.DrawImageOptions{}
+	pOpts.GeoM.Translate(float64(p.X), float64(p.Y-float32(p.Height/2)))
+	s.Img.Fill(p.Color)
+	screen.DrawImage(s.Img, pOpts)
+
+	// draw player's score if needed
+	if !ai {
+		w, _ := screen.Size()
+		if s.scorePrinted.score != p.Score && s.scorePrinted.printed {
+			s.scorePrinted.printed = false
+		}
+		if s.scorePrinted.score == 0 && !s.scorePrinted.printed {
+			s.scorePrinted.x = int(p.X + (float32(w/2)-p.X)/2)
+			s.scorePrinted.y = int(2 * 30)
+		}
+		if (s.scorePrinted.score == 0 || s.scorePrinted.score != p.Score) && !s.scorePrinted.printed {
+			s.scorePrinted.score = p.Score
+			s.scorePrinted.printed = true
+		}
+		str := strconv.Itoa(s.scorePrinted.score)
+		text.Draw(screen, str, scoreFont, s.scorePrinted.x, s.scorePrinted.y, p.Color)
+	}
+}
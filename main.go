@@ -1,146 +1,177 @@
 package main
 
 import (
+	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/dstoiko/go-pong-wasm/pong"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/ebitenutil"
 	"github.com/hajimehoshi/ebiten/inpututil"
-	"image/color"
-	"math"
+	"image"
+	"log"
 	"math/rand"
+	"os"
 	"runtime"
 )
 
 // Game is the structure of the game state
 type Game struct {
-	state    pong.GameState
-	aiMode   bool
-	ball     *pong.Ball
-	player1  *pong.Paddle
-	player2  *pong.Paddle
-	rally    int
-	level    int
-	maxScore int
-	Network  pong.Network
-	Net      int
-	Position int
-	rng      *rand.Rand
+	state       pong.GameState
+	aiMode      bool
+	world       *pong.World
+	keys        [2]*Keyboard
+	networks    [2]*pong.NetworkController
+	choices     [2]int
+	difficulty  pong.Difficulty
+	controllers [2]pong.Controller
+	ball        *BallSprite
+	player1     *PaddleSprite
+	player2     *PaddleSprite
+	rules       *pong.Rules
+	rng         *rand.Rand
+	seed        int64
+	netSeed     int64
+	record      *pong.Replay
+	output      string
+	matches     int
+	replay      *pong.Replay
+	network     [2]string
+	tick        int
+	speed       int
 }
 
+// The controllers that can be picked for each side in the menu
 const (
-	initBallVelocity = 5.0
-	initPaddleSpeed  = 10.0
-	speedUpdateCount = 6
-	speedIncrement   = 0.5
+	keyboardController = iota
+	trackerController
+	networkController
+	scriptController
+	aiController
 )
 
+var controllerNames = [...]string{"KEYBOARD", "TRACKER", "NETWORK", "SCRIPT", "AI"}
+
 const (
 	windowWidth  = 800
 	windowHeight = 600
-	Size         = 32
 )
 
-// NewGame creates an initializes a new game
-func NewGame(aiMode bool) *Game {
-	g := &Game{}
+// NewGame creates an initializes a new game, the default rules of each mode are used if rules is nil
+func NewGame(aiMode bool, rules *pong.Rules, perception pong.Perception, seed, netSeed int64) *Game {
+	g := &Game{
+		rules:   rules,
+		seed:    seed,
+		netSeed: netSeed,
+	}
 	g.init(aiMode)
-	g.Network = pong.NewNetwork(4, Size, 8)
-	g.rng = rand.New(rand.NewSource(1))
+	for i := range g.networks {
+		network, err := pong.NewNetworkController(netSeed+int64(i), perception, pong.DefaultNetworkConfig())
+		if err != nil {
+			panic(err)
+		}
+		g.networks[i] = network
+	}
+	g.choices = [2]int{networkController, keyboardController}
+	g.difficulty = pong.Medium
 	return g
 }
 
 func (g *Game) init(aiMode bool) {
 	g.state = pong.StartState
 	g.aiMode = aiMode
-	if aiMode {
-		g.maxScore = 100
-	} else {
-		g.maxScore = 11
+
+	world, err := pong.NewWorld(windowWidth, windowHeight, g.matchRules(), g.seed)
+	if err != nil {
+		panic(err)
 	}
+	g.world = world
+	g.rng = g.world.Rng
+	g.keys[0] = &Keyboard{Up: ebiten.KeyW, Down: ebiten.KeyS}
+	g.keys[1] = &Keyboard{Up: ebiten.KeyO, Down: ebiten.KeyK}
+	g.ball = NewBallSprite(g.world.Balls[0])
+	g.player1 = NewPaddleSprite(g.world.Player1)
+	g.player2 = NewPaddleSprite(g.world.Player2)
 
-	rng := rand.New(rand.NewSource(1))
-	up := pong.NewMatrix(4, 1, make([]float64, 4)...)
-	down := pong.NewMatrix(4, 1, make([]float64, 4)...)
-	for i := range up.Data {
-		up.Data[i] = rng.Float64()
+	InitFonts()
+}
+
+// matchRules returns the rules of the current mode
+func (g *Game) matchRules() pong.Rules {
+	if g.rules != nil {
+		return *g.rules
 	}
-	for i := range down.Data {
-		down.Data[i] = rng.Float64()
+	if g.aiMode {
+		return pong.AiRules()
 	}
-	g.player1 = &pong.Paddle{
-		Position: pong.Position{
-			X: pong.InitPaddleShift,
-			Y: float32(windowHeight / 2)},
-		Score:  0,
-		Speed:  initPaddleSpeed,
-		Width:  pong.InitPaddleWidth,
-		Height: pong.InitPaddleHeight,
-		Color:  pong.ObjColor,
-		Up:     ebiten.KeyW,
-		Down:   ebiten.KeyS,
-		UpV:    up,
-		DownV:  down,
+	return pong.DefaultRules()
+}
+
+// controller returns the chosen controller of a side
+func (g *Game) controller(side int) pong.Controller {
+	switch g.choices[side] {
+	case trackerController:
+		return pong.Tracker{}
+	case networkController:
+		return g.networks[side]
+	case scriptController:
+		return pong.NewSweepScript(30)
+	case aiController:
+		return pong.NewAI(g.difficulty, g.seed+int64(side))
 	}
-	g.player2 = &pong.Paddle{
-		Position: pong.Position{
-			X: windowWidth - pong.InitPaddleShift - pong.InitPaddleWidth,
-			Y: float32(windowHeight / 2)},
-		Score:  0,
-		Speed:  initPaddleSpeed,
-		Width:  pong.InitPaddleWidth,
-		Height: pong.InitPaddleHeight,
-		Color:  pong.ObjColor,
-		Up:     ebiten.KeyO,
-		Down:   ebiten.KeyK,
+	return g.keys[side]
+}
+
+// start starts a new match, player2 is the AI in AI games
+func (g *Game) start(aiMode bool, balls int) {
+	g.aiMode = aiMode
+	for i := range g.controllers {
+		g.controllers[i] = g.controller(i)
 	}
-	g.ball = &pong.Ball{
-		Position: pong.Position{
-			X: float32(windowWidth / 2),
-			Y: float32(windowHeight / 2)},
-		Radius:    pong.InitBallRadius,
-		Color:     pong.ObjColor,
-		XVelocity: initBallVelocity,
-		YVelocity: initBallVelocity,
+	if aiMode {
+		g.controllers[1] = pong.NewAI(g.difficulty, g.seed+1)
 	}
-	g.level = 0
-	g.ball.Img, _ = ebiten.NewImage(int(g.ball.Radius*2), int(g.ball.Radius*2), ebiten.FilterDefault)
-	g.player1.Img, _ = ebiten.NewImage(g.player1.Width, g.player1.Height, ebiten.FilterDefault)
-	g.player2.Img, _ = ebiten.NewImage(g.player2.Width, g.player2.Height, ebiten.FilterDefault)
-
-	pong.InitFonts()
+	g.world.Rules = g.matchRules()
+	g.world.SetBalls(balls)
+	g.world.Restart()
+	pong.ResetControllers(g.controllers[:]...)
+	g.state = pong.PlayState
+	g.startRecording()
 }
 
-func (g *Game) reset(screen *ebiten.Image, state pong.GameState) {
-	w, _ := screen.Size()
+func (g *Game) reset(state pong.GameState) {
 	g.state = state
-	g.rally = 0
-	g.level = 0
+	pong.ResetControllers(g.controllers[:]...)
 	if state == pong.StartState {
-		g.player1.Score = 0
-		g.player2.Score = 0
+		g.save()
+		g.world.Restart()
+		return
 	}
-	g.player1.Position = pong.Position{
-		X: pong.InitPaddleShift, Y: pong.GetCenter(screen).Y}
-	g.player2.Position = pong.Position{
-		X: float32(w - pong.InitPaddleShift - pong.InitPaddleWidth), Y: pong.GetCenter(screen).Y}
-	g.ball.Position = pong.GetCenter(screen)
-	g.ball.XVelocity = initBallVelocity
-	g.ball.YVelocity = initBallVelocity
+	g.world.Reset()
 }
 
 // Update updates the game state
 func (g *Game) Update(screen *ebiten.Image) error {
+	// the frame is drawn first so that controllers can look at it
+	g.Draw(screen)
+
 	switch g.state {
 	case pong.StartState:
-		if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		if inpututil.IsKeyJustPressed(ebiten.Key1) {
+			g.choices[0] = (g.choices[0] + 1) % len(controllerNames)
+		} else if inpututil.IsKeyJustPressed(ebiten.Key2) {
+			g.choices[1] = (g.choices[1] + 1) % len(controllerNames)
+		} else if inpututil.IsKeyJustPressed(ebiten.KeyD) {
+			g.difficulty = pong.Difficulties[(int(g.difficulty)+1)%len(pong.Difficulties)]
+		} else if inpututil.IsKeyJustPressed(ebiten.KeyC) {
 			g.state = pong.ControlsState
 		} else if inpututil.IsKeyJustPressed(ebiten.KeyA) {
-			g.aiMode = true
-			g.state = pong.PlayState
+			g.start(true, 1)
 		} else if inpututil.IsKeyJustPressed(ebiten.KeyV) {
-			g.aiMode = false
-			g.state = pong.PlayState
+			g.start(false, 1)
+		} else if inpututil.IsKeyJustPressed(ebiten.KeyX) {
+			g.start(false, pong.CrazyBalls)
 		}
 
 	case pong.ControlsState:
@@ -148,181 +179,111 @@ func (g *Game) Update(screen *ebiten.Image) error {
 			g.state = pong.StartState
 		}
 	case pong.PlayState:
-		w, _ := screen.Size()
-
 		if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
 			g.state = pong.PauseState
 			break
 		}
 
-		g.player1.Update(screen)
-		if g.aiMode {
-			g.player2.AiUpdate(g.ball)
-		} else {
-			g.player2.Update(screen)
-		}
-
-		xV := g.ball.XVelocity
-		g.ball.Update(g.player1, g.player2, screen)
-		// rally count
-		if xV*g.ball.XVelocity < 0 {
-			// score up when ball touches human player's paddle
-			if g.aiMode && g.ball.X < float32(w/2) {
-				g.player1.Score++
-			}
-
-			g.rally++
-
-			// spice things up
-			if (g.rally)%speedUpdateCount == 0 {
-				g.level++
-				g.ball.XVelocity += speedIncrement
-				g.ball.YVelocity += speedIncrement
-				g.player1.Speed += speedIncrement
-				g.player2.Speed += speedIncrement
-			}
-		}
-
-		if g.ball.X < 0 {
-			g.player2.Score++
-			if g.aiMode {
-				g.state = pong.GameOverState
-				break
-			}
-			g.reset(screen, pong.InterState)
-		} else if g.ball.X > float32(w) {
-			g.player1.Score++
-			if g.aiMode {
-				g.state = pong.GameOverState
-				break
-			}
-			g.reset(screen, pong.InterState)
+		if g.replay != nil {
+			g.playback()
+			break
 		}
 
-		if g.player1.Score == g.maxScore || g.player2.Score == g.maxScore {
-			g.state = pong.GameOverState
-		}
+		g.play(g.act(screen))
 
 	case pong.InterState, pong.PauseState:
 		if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
 			g.state = pong.PlayState
 		} else if inpututil.IsKeyJustPressed(ebiten.KeyR) {
-			g.reset(screen, pong.StartState)
+			if g.replay != nil {
+				g.seek(0)
+				break
+			}
+			g.reset(pong.StartState)
 		}
 
 	case pong.GameOverState:
 		if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
-			g.reset(screen, pong.StartState)
+			if g.replay != nil {
+				g.seek(0)
+				break
+			}
+			g.reset(pong.StartState)
 		}
 	}
+	if g.replay != nil {
+		g.replayControls()
+	}
 
-	g.Draw(screen)
-	{
-		width := g.Network.Width
-		rng := rand.New(rand.NewSource(1))
-		for i := range Size {
-			sum := 0.0
-			for h := range windowHeight {
-				for w := range windowWidth {
-					pixel := screen.At(w, h)
-					grayPixel := color.GrayModel.Convert(pixel).(color.Gray)
-					x := rng.Intn(6)
-					if x == 0 {
-						sum += float64(grayPixel.Y)
-					} else if x == 1 {
-						sum -= float64(grayPixel.Y)
-					}
-				}
-			}
-			g.Network.Neurons[g.Net].Vector[width+i] = sum
-		}
-		{
-			sum := 0.0
-			for i := range Size {
-				sum += math.Abs(g.Network.Neurons[g.Net].Vector[width+i])
-			}
-			for i := range Size {
-				ii := i / 2
-				g.Network.Neurons[g.Net].Vector[width+i] /= sum
-				if i&1 == 0 {
-					g.Network.Neurons[g.Net].Vector[width+i] +=
-						.1 * math.Sin(float64(g.Position)/math.Pow(10000, float64(2*ii)/Size))
-				} else {
-					g.Network.Neurons[g.Net].Vector[width+i] +=
-						.1 * math.Cos(float64(g.Position)/math.Pow(10000, float64(2*ii)/Size))
-				}
-			}
-		}
-		g.Position++
-		g.Net = (g.Net + 1) % 6
-		g.Network.Iterate()
-		/*up := pong.NCS(g.Network.Neurons[6].Vector[:width], g.player1.UpV.Data)
-		down := pong.NCS(g.Network.Neurons[7].Vector[:width], g.player1.DownV.Data)
-		if up > down {
-			g.player1.PressUp(screen)
-		} else {
-			g.player1.PressDown(screen)
-		}*/
-		vectors := make([]*pong.Vector[pong.Neuron], 8)
-		for ii := range 6 {
-			vector := pong.Vector[pong.Neuron]{}
-			vector.Meta = g.Network.Neurons[ii]
-			vector.Vector = g.Network.Neurons[ii].Vector[:width]
-			vectors[ii] = &vector
+	return nil
+}
 
+// act asks the controllers for this tick's inputs
+func (g *Game) act(frame image.Image) pong.Inputs {
+	observation1, observation2 := g.world.Observe(1), g.world.Observe(2)
+	observation1.Frame, observation2.Frame = frame, frame
+	return pong.Inputs{
+		Player1: g.controllers[0].Act(observation1),
+		Player2: g.controllers[1].Act(observation2),
+	}
+}
+
+// play advances the match by one tick
+func (g *Game) play(inputs pong.Inputs) {
+	if g.record != nil {
+		g.record.Record(inputs)
+	}
+	events := g.world.Step(inputs)
+	pong.Reward(events, g.controllers[0], g.controllers[1])
+	// score up when ball touches human player's paddle
+	if g.aiMode {
+		g.world.Player1.Score += events.Player1.Hits
+	}
+
+	if events.Player1.Points+events.Player2.Points > 0 {
+		if g.aiMode {
+			g.state = pong.GameOverState
+			g.save()
+			return
 		}
-		{
-			a := pong.Vector[pong.Neuron]{}
-			a.Meta = g.Network.Neurons[6]
-			a.Vector = g.Network.Neurons[6].Vector[:width]
-			vectors[6] = &a
-		}
-		{
-			a := pong.Vector[pong.Neuron]{}
-			a.Meta = g.Network.Neurons[7]
-			a.Vector = g.Network.Neurons[7].Vector[:width]
-			vectors[7] = &a
-		}
-		config := pong.Config{
-			Iterations: 16,
-			Size:       width,
-			Divider:    1,
-		}
-		pong.MorpheusFast(rng.Int63(), config, vectors)
-		sum := 0.0
-		sub := 0.0
-		for i := range vectors {
-			sum += vectors[i].Stddev
-			if i&1 == 0 {
-				sub += vectors[i].Stddev
-			}
-		}
-		fmt.Println(sub, sum, sub/sum)
-		if /*g.rng.Float64() > sub/sum*/ sub > .5 {
-			g.player1.PressUp(screen)
-			fmt.Println("up")
-		} else {
-			g.player1.PressDown(screen)
-			fmt.Println("down")
+		// in crazy mode the balls are served again on their own
+		if len(g.world.Balls) == 1 {
+			g.reset(pong.InterState)
 		}
 	}
-	return nil
+
+	if g.world.Winner() != 0 {
+		g.state = pong.GameOverState
+		g.save()
+	}
 }
 
 // Draw updates the game screen elements drawn
 func (g *Game) Draw(screen *ebiten.Image) error {
 	screen.Fill(pong.BgColor)
 
-	pong.DrawCaption(g.state, pong.ObjColor, screen)
-	pong.DrawBigText(g.state, pong.ObjColor, screen)
+	DrawCaption(g.state, pong.ObjColor, screen)
+	DrawBigText(g.state, g.difficulty, pong.ObjColor, screen)
+	if g.state == pong.StartState {
+		DrawPlayers([2]string{controllerNames[g.choices[0]], controllerNames[g.choices[1]]}, pong.ObjColor, screen)
+	}
 
 	if g.state != pong.ControlsState {
-		g.player1.Draw(screen, pong.ArcadeFont, false)
-		g.player2.Draw(screen, pong.ArcadeFont, g.aiMode)
-		g.ball.Draw(screen)
+		g.player1.Draw(screen, g.world.Player1, ArcadeFont, false)
+		g.player2.Draw(screen, g.world.Player2, ArcadeFont, g.aiMode)
+		for _, ball := range g.world.Balls {
+			g.ball.Draw(screen, ball)
+		}
+		if g.world.Rules.Countdown > 0 {
+			DrawCountdown(g.world, pong.ObjColor, screen)
+		}
 	}
 
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("TPS: %0.2f", ebiten.CurrentTPS()))
+	status := fmt.Sprintf("TPS: %0.2f", ebiten.CurrentTPS())
+	if g.replay != nil {
+		status += fmt.Sprintf("\nREPLAY %d/%d x%d", g.tick, len(g.replay.Inputs), g.speed)
+	}
+	ebitenutil.DebugPrint(screen, status)
 
 	return nil
 }
@@ -332,15 +293,128 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return windowWidth, windowHeight
 }
 
+// setTelemetry makes the networks and the world record to telemetry
+func (g *Game) setTelemetry(telemetry pong.Telemetry) {
+	for _, network := range g.networks {
+		network.Network.Telemetry = telemetry
+	}
+	g.world.Telemetry = telemetry
+}
+
+// loadNetworks loads the network of each player from its network file if there is one
+func (g *Game) loadNetworks() error {
+	for side, name := range g.network {
+		if name == "" {
+			continue
+		}
+		input, err := os.Open(name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		network, err := pong.ReadNetwork(input)
+		input.Close()
+		if err != nil {
+			return fmt.Errorf("invalid network %s: %w", name, err)
+		}
+		g.networks[side].Network = network
+	}
+	return nil
+}
+
+// saveNetworks writes the network of each player to its network file
+func (g *Game) saveNetworks() {
+	for side, name := range g.network {
+		if name == "" {
+			continue
+		}
+		output, err := os.Create(name)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		err = g.networks[side].Network.Write(output)
+		output.Close()
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runReplay(os.Args[2:])
+		return
+	}
+
+	seed := flag.Int64("seed", 1, "seed of the game random number generator")
+	netSeed := flag.Int64("netseed", 1, "seed of the network random number generator")
+	record := flag.String("record", "", "record the first match to a replay file and the next ones to numbered files next to it")
+	rulesFile := flag.String("rules", "", "load the match rules from a JSON file")
+	perception := flag.String("perception", string(pong.PixelPerception), "network input: pixels or observation")
+	network := flag.String("network", "", "load the network of player1 from a file if it exists and save it there on exit")
+	network2 := flag.String("network2", "", "load the network of player2 from a file if it exists and save it there on exit")
+	telemetryFile := flag.String("telemetry", "", "write the telemetry of the networks and the world to a JSONL file")
+	learn := flag.Bool("learn", false, "reward the network players for their hits and misses")
+	stack := flag.Int("stack", 1, "number of frames stacked for the network players")
+	difference := flag.Bool("difference", false, "stack the differences between consecutive frames instead of the older frames")
+	deadband := flag.Float64("deadband", 0, "how close to even the vote of a network has to be for its paddle to stay")
+	analog := flag.Bool("analog", false, "push the network paddles harder the more one side wins the vote")
+	flag.Parse()
+
+	if p := pong.Perception(*perception); p != pong.PixelPerception && p != pong.ObservationPerception {
+		log.Fatalf("invalid perception: %s", *perception)
+	}
+
+	var rules *pong.Rules
+	if *rulesFile != "" {
+		input, err := os.Open(*rulesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r, err := pong.LoadRules(input)
+		input.Close()
+		if err != nil {
+			log.Fatalf("invalid rules %s: %v", *rulesFile, err)
+		}
+		rules = &r
+	}
+
 	// On browsers, let's use fullscreen so that this is playable on any browsers.
 	// It is planned to ignore the given 'scale' apply fullscreen automatically on browsers (#571).
 	if runtime.GOARCH == "js" || runtime.GOOS == "js" {
 		ebiten.SetFullscreen(true)
 	}
 	ai := true
-	g := NewGame(ai)
-	if err := ebiten.RunGame(g); err != nil {
+	g := NewGame(ai, rules, pong.Perception(*perception), *seed, *netSeed)
+	g.output = *record
+	g.network = [2]string{*network, *network2}
+	err := g.loadNetworks()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *telemetryFile != "" {
+		output, err := os.Create(*telemetryFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer output.Close()
+		buffer := bufio.NewWriter(output)
+		defer buffer.Flush()
+		g.setTelemetry(pong.NewJSONL(buffer))
+	}
+	for _, network := range g.networks {
+		network.Stack = pong.FrameStack{Frames: *stack, Difference: *difference}
+		network.Deadband, network.Analog = *deadband, *analog
+		if *learn {
+			network.Eligibility = pong.NewEligibility(pong.LearningFrames, pong.LearningDecay, pong.LearningRate, pong.LearningReadoutRate)
+		}
+	}
+	err = ebiten.RunGame(g)
+	g.save()
+	g.saveNetworks()
+	if err != nil {
 		panic(err)
 	}
 }
@@ -0,0 +1,111 @@
+package pong
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Difficulty is the skill level of the AI
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Medium
+	Hard
+	Perfect
+)
+
+// Difficulties are all the difficulty levels
+var Difficulties = [...]Difficulty{Easy, Medium, Hard, Perfect}
+
+var difficultyNames = [...]string{"EASY", "MEDIUM", "HARD", "PERFECT"}
+
+func (d Difficulty) String() string {
+	return difficultyNames[d]
+}
+
+// skill is how well the AI plays at a difficulty
+type skill struct {
+	// delay is the number of ticks before reacting to a shot
+	delay int
+	// aim is the standard deviation of the aim error in paddle half heights
+	aim float64
+}
+
+var skills = [...]skill{
+	Easy:    {delay: 24, aim: 0.9},
+	Medium:  {delay: 14, aim: 0.55},
+	Hard:    {delay: 6, aim: 0.25},
+	Perfect: {delay: 0, aim: 0},
+}
+
+// AI is a controller that predicts where the ball will cross its paddle
+type AI struct {
+	Difficulty Difficulty
+	Rng        *rand.Rand
+	delay      int
+	aim        float32
+	incoming   bool
+	last       Input
+}
+
+// NewAI creates an AI controller, the aim errors are drawn from a generator seeded with seed
+func NewAI(difficulty Difficulty, seed int64) *AI {
+	return &AI{
+		Difficulty: difficulty,
+		Rng:        rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Act moves the paddle towards where the incoming ball will be
+func (a *AI) Act(o Observation) Input {
+	skill := skills[a.Difficulty]
+	ball, ok := o.Incoming()
+	incoming := ok && ball.XVelocity != 0 && (o.Player == 1) == (ball.XVelocity < 0)
+	if incoming != a.incoming {
+		// a new shot, it takes time to react
+		a.incoming = incoming
+		a.delay = skill.delay
+		a.aim = float32(a.Rng.NormFloat64()*skill.aim) * float32(o.Paddle.Height) / 2
+	}
+	if a.delay > 0 {
+		a.delay--
+		return a.last
+	}
+
+	target := float32(o.Height) / 2
+	if incoming {
+		x := o.Paddle.X - ball.Radius
+		if o.Player == 1 {
+			x = o.Paddle.X + float32(o.Paddle.Width) + ball.Radius
+		}
+		target = Predict(ball, x, o.Height) + a.aim
+	}
+	a.last = Toward(o.Paddle, target)
+	return a.last
+}
+
+// Predict returns the height at which the ball will reach x, bouncing off the walls of an arena of the given height
+func Predict(b Ball, x float32, height int) float32 {
+	if b.XVelocity == 0 {
+		return b.Y
+	}
+	t := (x - b.X) / b.XVelocity
+	if t < 0 {
+		return b.Y
+	}
+	y := float64(b.Y + b.YVelocity*t - b.Radius)
+	span := float64(height) - 2*float64(b.Radius)
+	if span <= 0 {
+		return b.Y
+	}
+	// unfold the bounces off the walls
+	y = math.Mod(y, 2*span)
+	if y < 0 {
+		y += 2 * span
+	}
+	if y > span {
+		y = 2*span - y
+	}
+	return float32(y) + b.Radius
+}
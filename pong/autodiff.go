@@ -0,0 +1,286 @@
+package pong
+
+import "fmt"
+
+// Node is a matrix computed on a tape with the gradient of the tape's output with respect to it
+type Node[T Float] struct {
+	Value    Matrix[T]
+	Gradient Matrix[T]
+	backward func()
+}
+
+// Tape records matrix operations so that the gradients of their inputs can be computed
+type Tape[T Float] struct {
+	nodes      []*Node[T]
+	parameters map[string]*Node[T]
+	// Err is the first error of an operation, the operations do nothing after it
+	Err error
+}
+
+// NewTape creates an empty tape
+func NewTape[T Float]() *Tape[T] {
+	return &Tape[T]{
+		parameters: make(map[string]*Node[T]),
+	}
+}
+
+// node records a value on the tape
+func (t *Tape[T]) node(value Matrix[T]) *Node[T] {
+	n := &Node[T]{
+		Value:    value,
+		Gradient: NewMatrix(value.Cols, value.Rows, make([]T, len(value.Data))...),
+	}
+	t.nodes = append(t.nodes, n)
+	return n
+}
+
+// fail records the first error of an operation and returns an empty node
+func (t *Tape[T]) fail(err error) *Node[T] {
+	if t.Err == nil {
+		t.Err = err
+	}
+	return &Node[T]{}
+}
+
+// Constant records a matrix the gradients are computed through
+func (t *Tape[T]) Constant(m Matrix[T]) *Node[T] {
+	if t.Err != nil {
+		return &Node[T]{}
+	}
+	if err := checkData("constant", m); err != nil {
+		return t.fail(err)
+	}
+	return t.node(m)
+}
+
+// Parameters records every matrix of a set, their gradients are returned by Gradients
+func (t *Tape[T]) Parameters(set Set[T]) map[string]*Node[T] {
+	nodes := make(map[string]*Node[T], len(set.ByName))
+	for name, m := range set.ByName {
+		n := t.Constant(*m)
+		t.parameters[name] = n
+		nodes[name] = n
+	}
+	return nodes
+}
+
+// Backward computes the gradients of the sum of the values of output, it returns the first error of the operations
+func (t *Tape[T]) Backward(output *Node[T]) error {
+	if t.Err != nil {
+		return t.Err
+	}
+	for _, n := range t.nodes {
+		clear(n.Gradient.Data)
+	}
+	for i := range output.Gradient.Data {
+		output.Gradient.Data[i] = 1
+	}
+	for i := len(t.nodes) - 1; i >= 0; i-- {
+		if t.nodes[i].backward != nil {
+			t.nodes[i].backward()
+		}
+	}
+	return nil
+}
+
+// Gradients returns the gradients of the matrices of a set recorded with Parameters, as a set with the same names
+func (t *Tape[T]) Gradients(set Set[T]) (Set[T], error) {
+	matrices := make([]Matrix[T], 0, len(set.Sizes))
+	for _, size := range set.Sizes {
+		n, ok := t.parameters[size.Name]
+		if !ok {
+			return Set[T]{}, fmt.Errorf("matrix %q is not on the tape", size.Name)
+		}
+		gradient := NewMatrix(n.Gradient.Cols, n.Gradient.Rows, append([]T(nil), n.Gradient.Data...)...)
+		gradient.Name = size.Name
+		matrices = append(matrices, gradient)
+	}
+	return newSet(matrices)
+}
+
+// reduce adds a gradient of the shape of m to the gradient of n, which was broadcast to m
+func reduce[T Float](gradient []T, n *Node[T]) {
+	size := len(n.Gradient.Data)
+	for i, value := range gradient {
+		n.Gradient.Data[i%size] += value
+	}
+}
+
+// MulT records a.MulT(b)
+func (t *Tape[T]) MulT(a, b *Node[T]) *Node[T] {
+	if t.Err != nil {
+		return &Node[T]{}
+	}
+	value, err := a.Value.CheckedMulT(b.Value)
+	if err != nil {
+		return t.fail(err)
+	}
+	o := t.node(value)
+	o.backward = func() {
+		columns, rows := a.Value.Cols, a.Value.Rows
+		for i := 0; i < b.Value.Rows; i++ {
+			bb, db := b.Value.Data[i*columns:(i+1)*columns], b.Gradient.Data[i*columns:(i+1)*columns]
+			for j := 0; j < rows; j++ {
+				g := o.Gradient.Data[i*rows+j]
+				aa, da := a.Value.Data[j*columns:(j+1)*columns], a.Gradient.Data[j*columns:(j+1)*columns]
+				for k := range columns {
+					da[k] += g * bb[k]
+					db[k] += g * aa[k]
+				}
+			}
+		}
+	}
+	return o
+}
+
+// Add records a.Add(b), b is broadcast like CheckedAdd
+func (t *Tape[T]) Add(a, b *Node[T]) *Node[T] {
+	if t.Err != nil {
+		return &Node[T]{}
+	}
+	value, err := a.Value.CheckedAdd(b.Value)
+	if err != nil {
+		return t.fail(err)
+	}
+	o := t.node(value)
+	o.backward = func() {
+		for i, g := range o.Gradient.Data {
+			a.Gradient.Data[i] += g
+		}
+		reduce(o.Gradient.Data, b)
+	}
+	return o
+}
+
+// Hadamard records a.Hadamard(b), b is broadcast like CheckedHadamard
+func (t *Tape[T]) Hadamard(a, b *Node[T]) *Node[T] {
+	if t.Err != nil {
+		return &Node[T]{}
+	}
+	value, err := a.Value.CheckedHadamard(b.Value)
+	if err != nil {
+		return t.fail(err)
+	}
+	o := t.node(value)
+	o.backward = func() {
+		size := len(b.Value.Data)
+		gradient := make([]T, len(o.Gradient.Data))
+		for i, g := range o.Gradient.Data {
+			a.Gradient.Data[i] += g * b.Value.Data[i%size]
+			gradient[i] = g * a.Value.Data[i]
+		}
+		reduce(gradient, b)
+	}
+	return o
+}
+
+// ReLu records a.ReLu()
+func (t *Tape[T]) ReLu(a *Node[T]) *Node[T] {
+	if t.Err != nil {
+		return &Node[T]{}
+	}
+	o := t.node(a.Value.ReLu())
+	o.backward = func() {
+		for i, g := range o.Gradient.Data {
+			if a.Value.Data[i] > 0 {
+				a.Gradient.Data[i] += g
+			}
+		}
+	}
+	return o
+}
+
+// Sigmoid records a.Sigmoid()
+func (t *Tape[T]) Sigmoid(a *Node[T]) *Node[T] {
+	if t.Err != nil {
+		return &Node[T]{}
+	}
+	o := t.node(a.Value.Sigmoid())
+	o.backward = func() {
+		for i, g := range o.Gradient.Data {
+			y := o.Value.Data[i]
+			a.Gradient.Data[i] += g * y * (1 - y)
+		}
+	}
+	return o
+}
+
+// Softmax records a.Softmax(temperature)
+func (t *Tape[T]) Softmax(a *Node[T], temperature T) *Node[T] {
+	if t.Err != nil {
+		return &Node[T]{}
+	}
+	o := t.node(a.Value.Softmax(temperature))
+	o.backward = func() {
+		columns := o.Value.Cols
+		for i := 0; i < len(o.Value.Data); i += columns {
+			y, g := o.Value.Data[i:i+columns], o.Gradient.Data[i:i+columns]
+			d := dot(y, g)
+			for j := range y {
+				a.Gradient.Data[i+j] += y[j] * (g[j] - d) / temperature
+			}
+		}
+	}
+	return o
+}
+
+// Everett records a.Everett()
+func (t *Tape[T]) Everett(a *Node[T]) *Node[T] {
+	if t.Err != nil {
+		return &Node[T]{}
+	}
+	o := t.node(a.Value.Everett())
+	o.backward = func() {
+		for i, value := range a.Value.Data {
+			switch {
+			case value > 0:
+				a.Gradient.Data[i] += o.Gradient.Data[2*i]
+			case value < 0:
+				a.Gradient.Data[i] += o.Gradient.Data[2*i+1]
+			}
+		}
+	}
+	return o
+}
+
+// SelfAttention records SelfAttention(Q, K, V)
+func (t *Tape[T]) SelfAttention(Q, K, V *Node[T]) *Node[T] {
+	if t.Err != nil {
+		return &Node[T]{}
+	}
+	value, err := CheckedSelfAttention(Q.Value, K.Value, V.Value)
+	if err != nil {
+		return t.fail(err)
+	}
+	o := t.node(value)
+	o.backward = func() {
+		q, k, v := Q.Value, K.Value, V.Value
+		attention, gradient := make([]T, q.Rows), make([]T, q.Rows)
+		for i := 0; i < k.Rows; i++ {
+			kk, dk := k.Data[i*k.Cols:(i+1)*k.Cols], K.Gradient.Data[i*k.Cols:(i+1)*k.Cols]
+			g := o.Gradient.Data[i*v.Cols : (i+1)*v.Cols]
+			// the attention of the row is recomputed like in the forward pass
+			for j := range q.Rows {
+				attention[j] = dot(kk, q.Data[j*q.Cols:(j+1)*q.Cols])
+			}
+			softmax(attention)
+			for j := range q.Rows {
+				vv, dv := v.Data[j*v.Cols:(j+1)*v.Cols], V.Gradient.Data[j*v.Cols:(j+1)*v.Cols]
+				gradient[j] = dot(g, vv)
+				for c, value := range g {
+					dv[c] += attention[j] * value
+				}
+			}
+			d := dot(attention, gradient)
+			for j := range q.Rows {
+				score := attention[j] * (gradient[j] - d)
+				qq, dq := q.Data[j*q.Cols:(j+1)*q.Cols], Q.Gradient.Data[j*q.Cols:(j+1)*q.Cols]
+				for c := range kk {
+					dk[c] += score * qq[c]
+					dq[c] += score * kk[c]
+				}
+			}
+		}
+	}
+	return o
+}
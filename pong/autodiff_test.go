@@ -0,0 +1,123 @@
+package pong
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// gradientTolerance is the largest difference between the gradients of the tape and the finite differences
+const gradientTolerance = 1e-6
+
+// gradientCheck compares the gradients of f computed by a tape with central finite differences of step epsilon,
+// f computes a matrix from the recorded matrices of the set and its values are summed,
+// it returns the largest difference relative to the size of the gradients
+func gradientCheck(t *testing.T, set Set[float64], epsilon float64, f func(tape *Tape[float64], nodes map[string]*Node[float64]) *Node[float64]) float64 {
+	t.Helper()
+	tape := NewTape[float64]()
+	err := tape.Backward(f(tape, tape.Parameters(set)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gradients, err := tape.Gradients(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loss := func() float64 {
+		tape := NewTape[float64]()
+		sum := 0.0
+		for _, value := range f(tape, tape.Parameters(set)).Value.Data {
+			sum += value
+		}
+		return sum
+	}
+	worst := 0.0
+	for name, m := range set.ByName {
+		for i, value := range m.Data {
+			m.Data[i] = value + epsilon
+			plus := loss()
+			m.Data[i] = value - epsilon
+			minus := loss()
+			m.Data[i] = value
+			numeric, analytic := (plus-minus)/(2*epsilon), gradients.ByName[name].Data[i]
+			difference := math.Abs(numeric-analytic) / math.Max(1, math.Abs(numeric)+math.Abs(analytic))
+			worst = math.Max(worst, difference)
+		}
+	}
+	return worst
+}
+
+func TestGradients(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func(name string, cols, rows int) Matrix[float64] {
+		m := NewMatrix(cols, rows, make([]float64, cols*rows)...)
+		m.Name = name
+		for i := range m.Data {
+			m.Data[i] = rng.NormFloat64()
+		}
+		return m
+	}
+	// the outputs are weighted by a random constant so that the sum of their values depends on every value,
+	// the weights are drawn again for each evaluation so they come from their own seed
+	var weights *rand.Rand
+	weighted := func(tape *Tape[float64], n *Node[float64]) *Node[float64] {
+		w := NewMatrix(n.Value.Cols, n.Value.Rows, make([]float64, n.Value.Cols*n.Value.Rows)...)
+		for i := range w.Data {
+			w.Data[i] = weights.NormFloat64()
+		}
+		return tape.Hadamard(n, tape.Constant(w))
+	}
+	tests := []struct {
+		name     string
+		matrices []Matrix[float64]
+		f        func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64]
+	}{
+		{"MulT", []Matrix[float64]{random("a", 5, 3), random("b", 5, 4)}, func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+			return tape.MulT(n["a"], n["b"])
+		}},
+		{"Add", []Matrix[float64]{random("a", 5, 3), random("b", 5, 3)}, func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+			return weighted(tape, tape.Add(n["a"], n["b"]))
+		}},
+		{"Add bias", []Matrix[float64]{random("a", 5, 3), random("b", 5, 1)}, func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+			return weighted(tape, tape.Add(n["a"], n["b"]))
+		}},
+		{"Hadamard", []Matrix[float64]{random("a", 5, 3), random("b", 5, 3)}, func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+			return tape.Hadamard(n["a"], n["b"])
+		}},
+		{"Hadamard scalar", []Matrix[float64]{random("a", 5, 3), random("b", 1, 1)}, func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+			return weighted(tape, tape.Hadamard(n["a"], n["b"]))
+		}},
+		{"ReLu", []Matrix[float64]{random("a", 5, 3)}, func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+			return weighted(tape, tape.ReLu(n["a"]))
+		}},
+		{"Sigmoid", []Matrix[float64]{random("a", 5, 3)}, func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+			return weighted(tape, tape.Sigmoid(n["a"]))
+		}},
+		{"Softmax", []Matrix[float64]{random("a", 5, 3)}, func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+			return weighted(tape, tape.Softmax(n["a"], 2))
+		}},
+		{"Everett", []Matrix[float64]{random("a", 5, 3)}, func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+			return weighted(tape, tape.Everett(n["a"]))
+		}},
+		{"SelfAttention", []Matrix[float64]{random("q", 5, 4), random("k", 5, 3), random("v", 6, 4)}, func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+			return weighted(tape, tape.SelfAttention(n["q"], n["k"], n["v"]))
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set, err := newSet(test.matrices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seed := rng.Int63()
+			f := func(tape *Tape[float64], n map[string]*Node[float64]) *Node[float64] {
+				weights = rand.New(rand.NewSource(seed))
+				return test.f(tape, n)
+			}
+			difference := gradientCheck(t, set, 1e-6, f)
+			if difference > gradientTolerance {
+				t.Fatalf("the gradients differ from the finite differences by %.3g", difference)
+			}
+		})
+	}
+}
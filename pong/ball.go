@@ -1,8 +1,8 @@
 package pong
 
 import (
-	"github.com/hajimehoshi/ebiten"
 	"image/color"
+	"math"
 )
 
 // Ball is a pong ball
@@ -11,50 +11,235 @@ type Ball struct {
 	Radius    float32
 	XVelocity float32
 	YVelocity float32
-	Color     color.Color
-	Img       *ebiten.Image
+	// Spin is how much the ball turns each tick in radians
+	Spin float32
+	// Wait is the number of ticks before the ball starts moving
+	Wait  int
+	Color color.Color
 }
 
 const (
-	InitBallRadius = 10.0
+	InitBallRadius   = 10.0
+	InitBallVelocity = 5.0
+	// MaxBounceAngle is the steepest angle from the horizontal the ball can travel at
+	MaxBounceAngle = math.Pi / 3
+	// MaxSpin is the spin given by a paddle moving at full speed
+	MaxSpin = 0.02
+	// SpinDecay is how much spin is kept from one tick to the next
+	SpinDecay = 0.95
 )
 
-func setBallPixels(c color.Color, ballImg *ebiten.Image) {
-	// TODO: set pixels for round effect
-	ballImg.Fill(c)
+// Speed returns the speed of the ball
+func (b *Ball) Speed() float32 {
+	return float32(math.Hypot(float64(b.XVelocity), float64(b.YVelocity)))
 }
 
-func (b *Ball) Update(leftPaddle *Paddle, rightPaddle *Paddle, screen *ebiten.Image) {
-	_, h := screen.Size()
-	b.X += b.XVelocity
-	b.Y += b.YVelocity
+// Accelerate increases the speed of the ball keeping its direction
+func (b *Ball) Accelerate(increment float32) {
+	speed := b.Speed()
+	if speed == 0 {
+		return
+	}
+	scale := (speed + increment) / speed
+	b.XVelocity *= scale
+	b.YVelocity *= scale
+}
+
+// steer points the ball at angle from the horizontal keeping its speed and horizontal direction
+func (b *Ball) steer(angle float64, direction float32) {
+	angle = max(-MaxBounceAngle, min(MaxBounceAngle, angle))
+	speed := float64(b.Speed())
+	b.XVelocity = direction * float32(speed*math.Cos(angle))
+	b.YVelocity = float32(speed * math.Sin(angle))
+}
+
+// curve turns the ball by its spin, the spin wears off over time
+func (b *Ball) curve() {
+	if b.Spin == 0 {
+		return
+	}
+	direction := float32(1)
+	if b.XVelocity < 0 {
+		direction = -1
+	}
+	angle := math.Atan2(float64(b.YVelocity), math.Abs(float64(b.XVelocity)))
+	b.steer(angle+float64(b.Spin), direction)
+	b.Spin *= SpinDecay
+	if math.Abs(float64(b.Spin)) < 1e-4 {
+		b.Spin = 0
+	}
+}
+
+// Collide bounces two balls off each other if they overlap
+func (b *Ball) Collide(o *Ball) {
+	dx, dy := o.X-b.X, o.Y-b.Y
+	distance := float32(math.Hypot(float64(dx), float64(dy)))
+	overlap := b.Radius + o.Radius - distance
+	if overlap <= 0 {
+		return
+	}
+	nx, ny := float32(1), float32(0)
+	if distance > 0 {
+		nx, ny = dx/distance, dy/distance
+	}
+
+	// push the balls apart
+	b.X -= nx * overlap / 2
+	b.Y -= ny * overlap / 2
+	o.X += nx * overlap / 2
+	o.Y += ny * overlap / 2
+
+	// exchange the velocity along the normal, the balls have the same mass
+	closing := (b.XVelocity-o.XVelocity)*nx + (b.YVelocity-o.YVelocity)*ny
+	if closing <= 0 {
+		return
+	}
+	b.XVelocity -= closing * nx
+	b.YVelocity -= closing * ny
+	o.XVelocity += closing * nx
+	o.YVelocity += closing * ny
+	for _, ball := range [...]*Ball{b, o} {
+		direction := float32(1)
+		if ball.XVelocity < 0 {
+			direction = -1
+		}
+		ball.steer(math.Atan2(float64(ball.YVelocity), math.Abs(float64(ball.XVelocity))), direction)
+	}
+}
+
+// bounce sends the ball back at an angle that depends on where it hit the paddle,
+// a moving paddle puts spin on the ball
+func (b *Ball) bounce(p *Paddle) {
+	offset := (b.Y - p.Y) / (float32(p.Height)/2 + b.Radius)
+	offset = max(-1, min(1, offset))
+	direction := float32(1)
+	if b.XVelocity > 0 {
+		direction = -1
+	}
+	b.steer(float64(offset)*MaxBounceAngle, direction)
+	b.Spin = 0
+	if p.Speed > 0 {
+		b.Spin = MaxSpin * p.Velocity / p.Speed
+	}
+}
+
+// axis is the axis of a collision normal
+type axis byte
 
-	// bounce off edges when getting to top/bottom
-	if b.Y-b.Radius > float32(h) {
-		b.YVelocity = -b.YVelocity
-		b.Y = float32(h) - b.Radius
-	} else if b.Y+b.Radius < 0 {
-		b.YVelocity = -b.YVelocity
-		b.Y = b.Radius
+const (
+	axisNone axis = iota
+	axisX
+	axisY
+)
+
+// maxBounces caps the number of collisions resolved in one tick
+const maxBounces = 8
+
+// Update moves the ball inside an arena of the given height, the motion is swept
+// so the ball can't tunnel through the walls or the paddles at any speed
+func (b *Ball) Update(leftPaddle *Paddle, rightPaddle *Paddle, height int) {
+	paddles := [...]*Paddle{leftPaddle, rightPaddle}
+	for _, p := range paddles {
+		b.unstick(p)
+	}
+
+	b.curve()
+
+	remaining := float32(1)
+	for range maxBounces {
+		t, normal, paddle := remaining, axisNone, (*Paddle)(nil)
+
+		// bounce off edges when getting to top/bottom
+		if b.YVelocity > 0 {
+			if hit := (float32(height) - b.Radius - b.Y) / b.YVelocity; hit < t {
+				t, normal = max(hit, 0), axisY
+			}
+		} else if b.YVelocity < 0 {
+			if hit := (b.Radius - b.Y) / b.YVelocity; hit < t {
+				t, normal = max(hit, 0), axisY
+			}
+		}
+
+		// bounce off paddles
+		for _, p := range paddles {
+			if hit, n, ok := b.sweep(p, t); ok {
+				t, normal, paddle = hit, n, p
+			}
+		}
+
+		b.X += b.XVelocity * t
+		b.Y += b.YVelocity * t
+		remaining -= t
+		switch normal {
+		case axisX:
+			b.bounce(paddle)
+		case axisY:
+			b.YVelocity = -b.YVelocity
+		default:
+			return
+		}
+	}
+}
+
+// bounds returns the paddle's box grown by the ball radius
+func (b *Ball) bounds(p *Paddle) (minX, minY, maxX, maxY float32) {
+	return p.X - b.Radius,
+		p.Y - float32(p.Height)/2 - b.Radius,
+		p.X + float32(p.Width) + b.Radius,
+		p.Y + float32(p.Height)/2 + b.Radius
+}
+
+// unstick pushes the ball out of a paddle that moved onto it
+func (b *Ball) unstick(p *Paddle) {
+	minX, minY, maxX, maxY := b.bounds(p)
+	if b.X <= minX || b.X >= maxX || b.Y <= minY || b.Y >= maxY {
+		return
+	}
+	if b.X > p.X+float32(p.Width)/2 {
+		b.X = maxX
+		if b.XVelocity < 0 {
+			b.XVelocity = -b.XVelocity
+		}
+	} else {
+		b.X = minX
+		if b.XVelocity > 0 {
+			b.XVelocity = -b.XVelocity
+		}
 	}
+}
 
-	// bounce off paddles
-	if b.X-b.Radius < leftPaddle.X+float32(leftPaddle.Width/2) &&
-		b.Y > leftPaddle.Y-float32(leftPaddle.Height/2) &&
-		b.Y < leftPaddle.Y+float32(leftPaddle.Height/2) {
-		b.XVelocity = -b.XVelocity
-		b.X = leftPaddle.X + float32(leftPaddle.Width/2) + b.Radius
-	} else if b.X+b.Radius > rightPaddle.X-float32(rightPaddle.Width/2) &&
-		b.Y > rightPaddle.Y-float32(rightPaddle.Height/2) &&
-		b.Y < rightPaddle.Y+float32(rightPaddle.Height/2) {
-		b.XVelocity = -b.XVelocity
-		b.X = rightPaddle.X - float32(rightPaddle.Width/2) - b.Radius
+// sweep finds when the ball moving for at most limit ticks first touches the paddle
+func (b *Ball) sweep(p *Paddle, limit float32) (float32, axis, bool) {
+	minX, minY, maxX, maxY := b.bounds(p)
+	enterX, exitX, ok := slab(b.X, b.XVelocity, minX, maxX)
+	if !ok {
+		return 0, axisNone, false
 	}
+	enterY, exitY, ok := slab(b.Y, b.YVelocity, minY, maxY)
+	if !ok {
+		return 0, axisNone, false
+	}
+	enter, exit := max(enterX, enterY), min(exitX, exitY)
+	if enter > exit || enter < 0 || enter >= limit {
+		return 0, axisNone, false
+	}
+	if enterX >= enterY {
+		return enter, axisX, true
+	}
+	return enter, axisY, true
 }
 
-func (b *Ball) Draw(screen *ebiten.Image) {
-	opts := &ebiten.DrawImageOptions{}
-	opts.GeoM.Translate(float64(b.X), float64(b.Y))
-	setBallPixels(b.Color, b.Img)
-	screen.DrawImage(b.Img, opts)
+// slab returns the interval of time during which a point moving along one axis is between low and high
+func slab(position, velocity, low, high float32) (enter, exit float32, ok bool) {
+	if velocity == 0 {
+		if position <= low || position >= high {
+			return 0, 0, false
+		}
+		return float32(math.Inf(-1)), float32(math.Inf(1)), true
+	}
+	enter, exit = (low-position)/velocity, (high-position)/velocity
+	if enter > exit {
+		enter, exit = exit, enter
+	}
+	return enter, exit, true
 }
@@ -0,0 +1,159 @@
+package pong
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBallUpdate(t *testing.T) {
+	const arena = 600
+	paddle := func(x, y float32) *Paddle {
+		return &Paddle{
+			Position: Position{X: x, Y: y},
+			Width:    InitPaddleWidth,
+			Height:   InitPaddleHeight,
+		}
+	}
+	tests := []struct {
+		name   string
+		ball   Ball
+		left   *Paddle
+		right  *Paddle
+		height int
+		check  func(t *testing.T, b Ball, left, right *Paddle)
+	}{
+		{
+			name:  "head on into the left paddle",
+			ball:  Ball{Position: Position{X: 200, Y: 300}, Radius: InitBallRadius, XVelocity: -400},
+			left:  paddle(50, 300),
+			right: paddle(730, 300),
+			check: func(t *testing.T, b Ball, left, right *Paddle) {
+				if b.XVelocity <= 0 {
+					t.Errorf("the ball didn't bounce: x velocity %v", b.XVelocity)
+				}
+				if b.X < left.X+float32(left.Width)+b.Radius {
+					t.Errorf("the ball tunneled into the paddle: x %v", b.X)
+				}
+			},
+		},
+		{
+			name:  "head on into the right paddle",
+			ball:  Ball{Position: Position{X: 600, Y: 300}, Radius: InitBallRadius, XVelocity: 400},
+			left:  paddle(50, 300),
+			right: paddle(730, 300),
+			check: func(t *testing.T, b Ball, left, right *Paddle) {
+				if b.XVelocity >= 0 {
+					t.Errorf("the ball didn't bounce: x velocity %v", b.XVelocity)
+				}
+				if b.X > right.X-b.Radius {
+					t.Errorf("the ball tunneled into the paddle: x %v", b.X)
+				}
+			},
+		},
+		{
+			name:  "top corner of the paddle face",
+			ball:  Ball{Position: Position{X: 170, Y: 245}, Radius: InitBallRadius, XVelocity: -200},
+			left:  paddle(50, 300),
+			right: paddle(730, 300),
+			check: func(t *testing.T, b Ball, left, right *Paddle) {
+				if b.XVelocity <= 0 {
+					t.Errorf("the ball didn't bounce: x velocity %v", b.XVelocity)
+				}
+				if b.X < left.X+float32(left.Width)+b.Radius {
+					t.Errorf("the ball tunneled into the paddle: x %v", b.X)
+				}
+			},
+		},
+		{
+			name:  "bottom corner of the paddle face",
+			ball:  Ball{Position: Position{X: 170, Y: 355}, Radius: InitBallRadius, XVelocity: -200},
+			left:  paddle(50, 300),
+			right: paddle(730, 300),
+			check: func(t *testing.T, b Ball, left, right *Paddle) {
+				if b.XVelocity <= 0 {
+					t.Errorf("the ball didn't bounce: x velocity %v", b.XVelocity)
+				}
+				if b.X < left.X+float32(left.Width)+b.Radius {
+					t.Errorf("the ball tunneled into the paddle: x %v", b.X)
+				}
+			},
+		},
+		{
+			name:  "diagonally onto the top of the paddle",
+			ball:  Ball{Position: Position{X: 170, Y: 150}, Radius: InitBallRadius, XVelocity: -100, YVelocity: 95},
+			left:  paddle(50, 300),
+			right: paddle(730, 300),
+			check: func(t *testing.T, b Ball, left, right *Paddle) {
+				if b.YVelocity >= 0 {
+					t.Errorf("the ball didn't bounce off the top: y velocity %v", b.YVelocity)
+				}
+				if b.Y > left.Y-float32(left.Height)/2-b.Radius {
+					t.Errorf("the ball tunneled into the paddle: y %v", b.Y)
+				}
+			},
+		},
+		{
+			name:  "bottom wall then the paddle in one tick",
+			ball:  Ball{Position: Position{X: 150, Y: 580}, Radius: InitBallRadius, XVelocity: -150, YVelocity: 40},
+			left:  paddle(50, 540),
+			right: paddle(730, 300),
+			check: func(t *testing.T, b Ball, left, right *Paddle) {
+				if b.XVelocity <= 0 {
+					t.Errorf("the ball didn't bounce off the paddle: x velocity %v", b.XVelocity)
+				}
+				if b.X < left.X+float32(left.Width)+b.Radius {
+					t.Errorf("the ball tunneled into the paddle: x %v", b.X)
+				}
+				if b.Y < b.Radius || b.Y > arena-b.Radius {
+					t.Errorf("the ball left the arena: y %v", b.Y)
+				}
+			},
+		},
+		{
+			name:  "top wall then the right paddle in one tick",
+			ball:  Ball{Position: Position{X: 650, Y: 20}, Radius: InitBallRadius, XVelocity: 150, YVelocity: -40},
+			left:  paddle(50, 300),
+			right: paddle(730, 60),
+			check: func(t *testing.T, b Ball, left, right *Paddle) {
+				if b.XVelocity >= 0 {
+					t.Errorf("the ball didn't bounce off the paddle: x velocity %v", b.XVelocity)
+				}
+				if b.X > right.X-b.Radius {
+					t.Errorf("the ball tunneled into the paddle: x %v", b.X)
+				}
+				if b.Y < b.Radius || b.Y > arena-b.Radius {
+					t.Errorf("the ball left the arena: y %v", b.Y)
+				}
+			},
+		},
+		{
+			// the ball would hit the walls 50 times, it stops at the maxBounces-th hit:
+			// the first hit is after .01 ticks and then every .02 ticks
+			name:   "bounces are capped",
+			ball:   Ball{Position: Position{X: 400, Y: 20}, Radius: InitBallRadius, XVelocity: 10, YVelocity: 1000},
+			left:   paddle(50, 300),
+			right:  paddle(730, 300),
+			height: 40,
+			check: func(t *testing.T, b Ball, left, right *Paddle) {
+				moved := .01 + .02*float32(maxBounces-1)
+				if math.Abs(float64(b.X-(400+10*moved))) > 1e-3 {
+					t.Errorf("the ball moved to x %v, it should stop after %d bounces at %v", b.X, maxBounces, 400+10*moved)
+				}
+				if b.Y < b.Radius-1e-3 || b.Y > 40-b.Radius+1e-3 {
+					t.Errorf("the ball left the arena: y %v", b.Y)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			height := test.height
+			if height == 0 {
+				height = arena
+			}
+			b := test.ball
+			b.Update(test.left, test.right, height)
+			test.check(t, b, test.left, test.right)
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package pong
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ContainerVersion is the version of the matrix container format
+const ContainerVersion = 1
+
+var containerMagic = [4]byte{'P', 'M', 'A', 'T'}
+
+// DType is the type of the values of a matrix in a container
+type DType uint8
+
+const (
+	// Float32 is a little-endian IEEE 754 float32
+	Float32 DType = 1
+	// Float64 is a little-endian IEEE 754 float64
+	Float64 DType = 2
+)
+
+func (d DType) String() string {
+	switch d {
+	case Float32:
+		return "float32"
+	case Float64:
+		return "float64"
+	}
+	return fmt.Sprintf("dtype(%d)", uint8(d))
+}
+
+// Width returns the number of bytes of a value
+func (d DType) Width() int {
+	if d == Float32 {
+		return 4
+	}
+	return 8
+}
+
+const (
+	// maxTensorName is the longest name of a matrix in a container
+	maxTensorName = math.MaxUint16
+	// maxTensorValues is the largest number of values of a matrix in a container
+	maxTensorValues = 1 << 30
+	// readChunk is the number of values read at once, so that a short input fails before a large matrix is allocated
+	readChunk = 1 << 16
+)
+
+// containerHeader starts a container, it is followed by the matrices
+type containerHeader struct {
+	Magic   [4]byte
+	Version uint16
+	Tensors uint32
+}
+
+// tensorHeader starts a matrix, it is followed by the name and the values row by row
+type tensorHeader struct {
+	DType DType
+	Name  uint16
+	Cols  uint32
+	Rows  uint32
+}
+
+// dtype returns the dtype of the values of a matrix
+func dtype[T Float]() DType {
+	var value T
+	if _, ok := any(value).(float32); ok {
+		return Float32
+	}
+	return Float64
+}
+
+// Write writes the matrix as a container of one matrix
+func (m Matrix[T]) Write(output io.Writer) error {
+	err := writeContainer(output, 1)
+	if err != nil {
+		return err
+	}
+	return writeTensor(output, m.Name, m)
+}
+
+// Read reads a container of one matrix into the matrix, the values are converted to the type of the matrix
+func (m *Matrix[T]) Read(input io.Reader) error {
+	tensors, err := readContainer(input)
+	if err != nil {
+		return err
+	}
+	if tensors != 1 {
+		return fmt.Errorf("the container should hold 1 matrix: %d", tensors)
+	}
+	matrix, err := readTensor[T](input)
+	if err != nil {
+		return err
+	}
+	*m = matrix
+	return nil
+}
+
+// Save writes the named matrices of the set as a container, in the order of the sizes
+func (s Set[T]) Save(output io.Writer) error {
+	matrices, err := s.ordered()
+	if err != nil {
+		return err
+	}
+	err = writeContainer(output, len(matrices))
+	if err != nil {
+		return err
+	}
+	for i, matrix := range matrices {
+		err = writeTensor(output, s.Sizes[i].Name, *matrix)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ordered returns the named matrices of the set in the order of the sizes,
+// every size should have a matrix and every matrix a size so that nothing is dropped when the set is written
+func (s Set[T]) ordered() ([]*Matrix[T], error) {
+	matrices := make([]*Matrix[T], 0, len(s.Sizes))
+	sized := make(map[string]bool, len(s.Sizes))
+	for _, size := range s.Sizes {
+		matrix, ok := s.ByName[size.Name]
+		if !ok {
+			return nil, fmt.Errorf("no matrix named %q", size.Name)
+		}
+		if sized[size.Name] {
+			return nil, fmt.Errorf("two sizes are named %q", size.Name)
+		}
+		sized[size.Name] = true
+		matrices = append(matrices, matrix)
+	}
+	for name := range s.ByName {
+		if !sized[name] {
+			return nil, fmt.Errorf("matrix %q has no size in the set", name)
+		}
+	}
+	return matrices, nil
+}
+
+// LoadSet reads a set written by Set.Save, the values are converted to the type of the set
+func LoadSet[T Float](input io.Reader) (Set[T], error) {
+	tensors, err := readContainer(input)
+	if err != nil {
+		return Set[T]{}, err
+	}
+	var matrices []Matrix[T]
+	for range tensors {
+		matrix, err := readTensor[T](input)
+		if err != nil {
+			return Set[T]{}, err
+		}
+		matrices = append(matrices, matrix)
+	}
+	return newSet(matrices)
+}
+
+// newSet creates a set of named matrices
+func newSet[T Float](matrices []Matrix[T]) (Set[T], error) {
+	set := Set[T]{
+		Sizes:   make([]Size, len(matrices)),
+		ByIndex: matrices,
+		ByName:  make(map[string]*Matrix[T], len(matrices)),
+	}
+	for i := range set.ByIndex {
+		name := set.ByIndex[i].Name
+		if _, ok := set.ByName[name]; ok {
+			return Set[T]{}, fmt.Errorf("two matrices are named %q", name)
+		}
+		set.Sizes[i] = set.ByIndex[i].Size
+		set.ByName[name] = &set.ByIndex[i]
+	}
+	return set, nil
+}
+
+func writeContainer(output io.Writer, tensors int) error {
+	header := containerHeader{
+		Magic:   containerMagic,
+		Version: ContainerVersion,
+		Tensors: uint32(tensors),
+	}
+	return binary.Write(output, binary.LittleEndian, &header)
+}
+
+func readContainer(input io.Reader) (int, error) {
+	header := containerHeader{}
+	err := binary.Read(input, binary.LittleEndian, &header)
+	if err != nil {
+		return 0, err
+	}
+	if header.Magic != containerMagic {
+		return 0, errors.New("not a matrix container")
+	}
+	if header.Version != ContainerVersion {
+		return 0, fmt.Errorf("unsupported matrix container version: %d", header.Version)
+	}
+	return int(header.Tensors), nil
+}
+
+func writeTensor[T Float](output io.Writer, name string, m Matrix[T]) error {
+	if len(name) > maxTensorName {
+		return fmt.Errorf("the name of the matrix is too long: %d", len(name))
+	}
+	if len(m.Data) != m.Cols*m.Rows {
+		return fmt.Errorf("%d values don't fit a %dx%d matrix", len(m.Data), m.Cols, m.Rows)
+	}
+	header := tensorHeader{
+		DType: dtype[T](),
+		Name:  uint16(len(name)),
+		Cols:  uint32(m.Cols),
+		Rows:  uint32(m.Rows),
+	}
+	err := binary.Write(output, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(output, name)
+	if err != nil {
+		return err
+	}
+	return writeData(output, m.Data)
+}
+
+func readTensor[T Float](input io.Reader) (Matrix[T], error) {
+	header := tensorHeader{}
+	err := binary.Read(input, binary.LittleEndian, &header)
+	// the container said there is one more matrix, so the input can't end here
+	if err == io.EOF {
+		return Matrix[T]{}, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return Matrix[T]{}, err
+	}
+	name := make([]byte, header.Name)
+	_, err = io.ReadFull(input, name)
+	if err == io.EOF {
+		return Matrix[T]{}, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return Matrix[T]{}, err
+	}
+	if uint64(header.Cols)*uint64(header.Rows) > maxTensorValues {
+		return Matrix[T]{}, fmt.Errorf("matrix %q is too large: %dx%d", name, header.Cols, header.Rows)
+	}
+	m := Matrix[T]{Size: Size{Name: string(name), Cols: int(header.Cols), Rows: int(header.Rows)}}
+	switch header.DType {
+	case Float32:
+		m.Data, err = readData[float32, T](input, m.Cols*m.Rows)
+	case Float64:
+		m.Data, err = readData[float64, T](input, m.Cols*m.Rows)
+	default:
+		return Matrix[T]{}, fmt.Errorf("matrix %q has an unknown dtype: %s", m.Name, header.DType)
+	}
+	if err != nil {
+		return Matrix[T]{}, err
+	}
+	return m, nil
+}
+
+// writeData writes values as little-endian floats
+func writeData[T Float](output io.Writer, data []T) error {
+	return binary.Write(output, binary.LittleEndian, data)
+}
+
+// readData reads n little-endian floats of type S and converts them to T
+func readData[S, T Float](input io.Reader, n int) ([]T, error) {
+	return readOrdered[S, T](input, binary.LittleEndian, n)
+}
+
+// readOrdered reads n floats of type S in the given byte order and converts them to T,
+// the values are read in chunks so that the memory grows with what was actually read
+func readOrdered[S, T Float](input io.Reader, order binary.ByteOrder, n int) ([]T, error) {
+	values := make([]T, 0, min(n, readChunk))
+	chunk := make([]S, min(n, readChunk))
+	for len(values) < n {
+		chunk = chunk[:min(n-len(values), readChunk)]
+		err := binary.Read(input, order, chunk)
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+		for _, value := range chunk {
+			values = append(values, T(value))
+		}
+	}
+	return values, nil
+}
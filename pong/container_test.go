@@ -0,0 +1,83 @@
+package pong
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestSetRoundTrip(t *testing.T) {
+	t.Run("f32", func(t *testing.T) { testSetRoundTrip[float32](t) })
+	t.Run("f64", func(t *testing.T) { testSetRoundTrip[float64](t) })
+}
+
+func testSetRoundTrip[T Float](t *testing.T) {
+	set := goldenSet[T](t)
+	output := bytes.Buffer{}
+	err := set.Save(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := LoadSet[T](&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equalSets(t, read, set)
+	for _, size := range set.Sizes {
+		matrix, err := read.Lookup(size.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		equalMatrices(t, matrix, *set.ByName[size.Name])
+	}
+}
+
+func TestMatrixRoundTrip(t *testing.T) {
+	m := goldenWeights[float64]()
+	output := bytes.Buffer{}
+	err := m.Write(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := Matrix[float64]{}
+	err = read.Read(bytes.NewReader(output.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	equalMatrices(t, read, m)
+
+	// a float64 file is converted when it is read into a float32 matrix
+	converted := Matrix[float32]{}
+	err = converted.Read(bytes.NewReader(output.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	equalMatrices(t, converted, goldenWeights[float32]())
+}
+
+func TestLoadSetTruncated(t *testing.T) {
+	output := bytes.Buffer{}
+	err := goldenSet[float64](t).Save(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := output.Bytes()
+	for length := 1; length < len(saved); length++ {
+		_, err := LoadSet[float64](bytes.NewReader(saved[:length]))
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("%d of %d bytes: the error should be %v: %v", length, len(saved), io.ErrUnexpectedEOF, err)
+		}
+	}
+}
+
+func TestSaveMissingSize(t *testing.T) {
+	set := goldenSet[float64](t)
+	extra := NewMatrix[float64](1, 1, 3)
+	extra.Name = "extra"
+	set.ByName["extra"] = &extra
+	err := set.Save(io.Discard)
+	if err == nil {
+		t.Fatal("a set with a matrix missing from its sizes was saved")
+	}
+}
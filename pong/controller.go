@@ -0,0 +1,375 @@
+package pong
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// Observation is what a controller sees of the world during a tick
+type Observation struct {
+	// Player is the number of the observing player
+	Player   int
+	Width    int
+	Height   int
+	Balls    []Ball
+	Paddle   Paddle
+	Opponent Paddle
+	Level    int
+	Rally    int
+	// Ticks is the number of ticks played in the match
+	Ticks int
+	// Frame is the last rendered frame, nil when running headless
+	Frame image.Image
+}
+
+// featureVelocity is the velocity that is scaled to 1 in the features
+const featureVelocity = 10.0
+
+// NumFeatures is the length of the feature vector of an observation
+const NumFeatures = 14
+
+// Observe returns what a player sees of the world
+func (w *World) Observe(player int) Observation {
+	o := Observation{
+		Player: player,
+		Width:  w.Width,
+		Height: w.Height,
+		Balls:  make([]Ball, len(w.Balls)),
+		Level:  w.Level,
+		Rally:  w.Rally,
+		Ticks:  w.Ticks,
+	}
+	for i, ball := range w.Balls {
+		o.Balls[i] = *ball
+	}
+	if player == 1 {
+		o.Paddle, o.Opponent = *w.Player1, *w.Player2
+	} else {
+		o.Paddle, o.Opponent = *w.Player2, *w.Player1
+	}
+	return o
+}
+
+// Incoming returns the ball that will reach the player's paddle first,
+// or the closest ball if none is heading towards it
+func (o Observation) Incoming() (Ball, bool) {
+	found, incoming, best := false, Ball{}, float32(math.MaxFloat32)
+	for _, ball := range o.Balls {
+		if ball.Wait > 0 {
+			continue
+		}
+		distance := ball.X - o.Paddle.X
+		if distance < 0 {
+			distance = -distance
+		}
+		// balls heading away are only considered when there are no incoming balls
+		if ball.XVelocity == 0 || (o.Player == 1) != (ball.XVelocity < 0) {
+			distance += float32(o.Width)
+		} else {
+			distance /= max(ball.XVelocity, -ball.XVelocity)
+		}
+		if distance < best {
+			found, incoming, best = true, ball, distance
+		}
+	}
+	return incoming, found
+}
+
+// Features returns the observation from the player's point of view as numbers of about [-1, 1]:
+// the incoming ball's position and velocity, both paddles' positions, velocities and speeds,
+// the score lead, the level and the rally
+func (o Observation) Features() []float64 {
+	w, h := float64(o.Width), float64(o.Height)
+	ball, _ := o.Incoming()
+	lead := float64(o.Paddle.Score - o.Opponent.Score)
+	return []float64{
+		float64(ball.X) / w,
+		float64(ball.Y) / h,
+		float64(ball.XVelocity) / featureVelocity,
+		float64(ball.YVelocity) / featureVelocity,
+		float64(o.Paddle.X) / w,
+		float64(o.Paddle.Y) / h,
+		float64(o.Paddle.Velocity) / featureVelocity,
+		float64(o.Paddle.Speed) / featureVelocity,
+		float64(o.Opponent.Y) / h,
+		float64(o.Opponent.Velocity) / featureVelocity,
+		float64(o.Opponent.Speed) / featureVelocity,
+		math.Tanh(lead / 5),
+		math.Tanh(float64(o.Level) / 5),
+		math.Tanh(float64(o.Rally) / 10),
+	}
+}
+
+// Controller moves a paddle
+type Controller interface {
+	// Act maps an observation to the paddle's input for the tick
+	Act(observation Observation) Input
+}
+
+// Resetter is a controller that remembers the last frames of a point
+type Resetter interface {
+	Controller
+	// Reset forgets the frames of the last point
+	Reset()
+}
+
+// ResetControllers makes the controllers that remember the last frames forget them, it is called when the world is reset
+func ResetControllers(controllers ...Controller) {
+	for _, controller := range controllers {
+		if resetter, ok := controller.(Resetter); ok {
+			resetter.Reset()
+		}
+	}
+}
+
+// Tracker is a controller that follows the ball as fast as the paddle can move
+type Tracker struct{}
+
+// Act moves the paddle towards the incoming ball
+func (Tracker) Act(o Observation) Input {
+	ball, ok := o.Incoming()
+	if !ok {
+		return Input{}
+	}
+	return Toward(o.Paddle, ball.Y)
+}
+
+// Toward returns the input that moves the paddle towards y,
+// the paddle is let go early enough to come to a stop at y when it decelerates slowly
+func Toward(p Paddle, y float32) Input {
+	deadzone := p.Speed / 2
+	braking := float32(0)
+	if p.Deceleration > 0 {
+		braking = p.Velocity * p.Velocity / (2 * p.Deceleration)
+	}
+	if p.Y > y+deadzone && (p.Velocity >= 0 || p.Y-y > braking) {
+		return Input{Up: true}
+	} else if p.Y < y-deadzone && (p.Velocity <= 0 || y-p.Y > braking) {
+		return Input{Down: true}
+	}
+	return Input{}
+}
+
+// Script is a controller that plays a fixed sequence of inputs
+type Script struct {
+	Inputs []Input
+	// Loop restarts the sequence once it is over
+	Loop bool
+	tick int
+}
+
+// NewSweepScript creates a script that moves the paddle up and down for ticks each way
+func NewSweepScript(ticks int) *Script {
+	s := &Script{
+		Inputs: make([]Input, 2*ticks),
+		Loop:   true,
+	}
+	for i := range s.Inputs {
+		if i < ticks {
+			s.Inputs[i].Up = true
+		} else {
+			s.Inputs[i].Down = true
+		}
+	}
+	return s
+}
+
+// Act returns the next input of the sequence
+func (s *Script) Act(o Observation) Input {
+	if s.tick >= len(s.Inputs) {
+		if !s.Loop || len(s.Inputs) == 0 {
+			return Input{}
+		}
+		s.tick = 0
+	}
+	input := s.Inputs[s.tick]
+	s.tick++
+	return input
+}
+
+// Perception is what the network controller looks at
+type Perception string
+
+const (
+	// PixelPerception embeds the pixels of the rendered frame
+	PixelPerception Perception = "pixels"
+	// ObservationPerception embeds the features of the observation
+	ObservationPerception Perception = "observation"
+)
+
+// PixelDownsample is the size of the blocks of pixels the network controller samples one pixel from
+const PixelDownsample = 4
+
+// NetworkController is a controller driven by a network that looks at the rendered frames or at the observations
+type NetworkController struct {
+	Network    Network
+	Perception Perception
+	Net        int
+	Position   int
+	// Deadband is how close to even the vote has to be for the paddle to stay
+	Deadband float64
+	// Analog pushes the paddle harder the more one side wins the vote
+	Analog bool
+	// Stack stacks the last frames before they are embedded
+	Stack FrameStack
+	// Eligibility credits the rewards to the recent walks of the network, nil when the controller doesn't learn
+	Eligibility *Eligibility
+	action      Input
+	projection  *Projection
+	pixels      []float64
+}
+
+// NewNetworkController creates a network controller
+func NewNetworkController(seed int64, perception Perception, config NetworkConfig) (*NetworkController, error) {
+	network, err := NewNetwork(seed, config)
+	if err != nil {
+		return nil, err
+	}
+	return &NetworkController{
+		Network:    network,
+		Perception: perception,
+	}, nil
+}
+
+// project projects input into the embedding, the projection is generated from the seed of the network on first use
+// and again when the size of the input or of the embedding changes
+func (c *NetworkController) project(input, embedding []float64) {
+	if c.projection == nil || c.projection.Cols != len(input) || c.projection.Rows != len(embedding) {
+		seed, _ := c.Network.Source.State()
+		c.projection = NewProjection(seed, len(embedding), len(input))
+	}
+	c.projection.Apply(input, embedding)
+}
+
+// embed projects the stack of what the controller perceived into the embedding,
+// it returns false if there is nothing to perceive
+func (c *NetworkController) embed(o Observation, embedding []float64) bool {
+	switch c.Perception {
+	case ObservationPerception:
+		c.project(c.Stack.Push(o.Features()), embedding)
+		return true
+	}
+
+	if o.Frame == nil {
+		return false
+	}
+	c.pixels = Grayscale(o.Frame, PixelDownsample, c.pixels)
+	c.project(c.Stack.Push(c.pixels), embedding)
+	return true
+}
+
+// Act embeds what the controller perceives into the network and decides whether to go up or down
+// from the votes of the network and the readout of the perception, the previous decision is kept when there is no frame to look at
+func (c *NetworkController) Act(o Observation) Input {
+	width := c.Network.Width
+	embedding := c.Network.Embedding
+	vector := c.Network.Neurons[c.Net].Vector
+	if !c.embed(o, vector[width:width+embedding]) {
+		return c.action
+	}
+	rng := rand.New(rand.NewSource(1))
+	var perception []float64
+	{
+		sum := 0.0
+		for i := range embedding {
+			sum += math.Abs(vector[width+i])
+		}
+		for i := range embedding {
+			if sum > 0 {
+				vector[width+i] /= sum
+			}
+		}
+		// the readout looks at the perception without the position in the sequence
+		perception = slices.Clone(vector[width : width+embedding])
+		for i := range embedding {
+			ii := i / 2
+			if i&1 == 0 {
+				vector[width+i] +=
+					.1 * math.Sin(float64(c.Position)/math.Pow(10000, float64(2*ii)/float64(embedding)))
+			} else {
+				vector[width+i] +=
+					.1 * math.Cos(float64(c.Position)/math.Pow(10000, float64(2*ii)/float64(embedding)))
+			}
+		}
+	}
+	c.Position++
+	c.Net = (c.Net + 1) % c.Network.Inputs
+	c.Network.Iterate()
+	// the input neurons and then the action neurons take part in the decision
+	vectors := make([]*Vector[Neuron], c.Network.Size())
+	for ii := range vectors {
+		vector := Vector[Neuron]{}
+		vector.Meta = c.Network.Neurons[ii]
+		vector.Vector = c.Network.Neurons[ii].Vector[:width]
+		vectors[ii] = &vector
+	}
+	config := Config{
+		Iterations: 16,
+		Size:       width,
+		Divider:    1,
+	}
+	MorpheusFast(rng.Int63(), config, vectors)
+	// the even neurons vote up and the odd neurons vote down
+	sum := 0.0
+	sub := 0.0
+	for i := range vectors {
+		sum += vectors[i].Stddev
+		if i&1 == 0 {
+			sub += vectors[i].Stddev
+		}
+	}
+	c.action = c.decide(sub, sum, c.Network.Readout(perception))
+	if c.Eligibility != nil {
+		c.Eligibility.Trace(&c.Network, perception, c.action.Action())
+	}
+	c.Network.Telemetry.Record(Entry{
+		Kind: DecisionKind,
+		Value: Decision{
+			Player: o.Player,
+			Tick:   o.Ticks,
+			Sub:    sub,
+			Sum:    sum,
+			Action: c.action.Action(),
+			Axis:   c.action.Axis,
+		},
+	})
+	return c.action
+}
+
+// analogGain is how much the vote of the network is scaled to push the paddle in analog mode
+const analogGain = 10
+
+// decide maps the share of the up votes and the readout to an input: the paddle goes up when the up side leads by more than
+// the deadband, down when it trails by more than the deadband and stays otherwise
+func (c *NetworkController) decide(up, votes, readout float64) Input {
+	if votes <= 0 {
+		return NewInput(ActionDown)
+	}
+	// the votes are compared to each other, the sum of the up votes alone stays far below .5,
+	// the readout only leans one way once the network was rewarded
+	lead := up/votes - .5 + readout
+	if math.Abs(lead) < c.Deadband {
+		return NewInput(ActionStay)
+	}
+	if c.Analog {
+		return Input{Axis: float32(max(-1, min(-analogGain*lead, 1)))}
+	}
+	if lead > 0 {
+		return NewInput(ActionUp)
+	}
+	return NewInput(ActionDown)
+}
+
+// Reset forgets the stacked frames so that the next point isn't stacked with the last one
+func (c *NetworkController) Reset() {
+	c.Stack.Reset()
+}
+
+// Reward credits a reward to the recent walks of the network if the controller learns
+func (c *NetworkController) Reward(reward float64) {
+	if c.Eligibility != nil {
+		c.Eligibility.Reward(&c.Network, reward)
+	}
+}
@@ -0,0 +1,111 @@
+package pong
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+)
+
+const (
+	// InitialElo is the rating of a new player
+	InitialElo = 1500
+	// EloK is the most a rating can change after a game
+	EloK = 32
+)
+
+// Rating is the Elo rating of a player with its record
+type Rating struct {
+	Name   string  `json:"name"`
+	Elo    float64 `json:"elo"`
+	Games  int     `json:"games"`
+	Wins   int     `json:"wins"`
+	Losses int     `json:"losses"`
+	Draws  int     `json:"draws"`
+}
+
+// EloTable is the Elo ratings of players, such as generations of saved networks
+type EloTable struct {
+	Ratings map[string]*Rating `json:"ratings"`
+}
+
+// NewEloTable creates an empty Elo table
+func NewEloTable() *EloTable {
+	return &EloTable{
+		Ratings: make(map[string]*Rating),
+	}
+}
+
+// ReadEloTable reads an Elo table in JSON
+func ReadEloTable(input io.Reader) (*EloTable, error) {
+	table := NewEloTable()
+	err := json.NewDecoder(input).Decode(table)
+	if err != nil {
+		return nil, err
+	}
+	if table.Ratings == nil {
+		table.Ratings = make(map[string]*Rating)
+	}
+	return table, nil
+}
+
+// Write writes the Elo table in JSON
+func (t *EloTable) Write(output io.Writer) error {
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(t)
+}
+
+// Player returns the rating of a player, new players start at InitialElo
+func (t *EloTable) Player(name string) *Rating {
+	rating, ok := t.Ratings[name]
+	if !ok {
+		rating = &Rating{
+			Name: name,
+			Elo:  InitialElo,
+		}
+		t.Ratings[name] = rating
+	}
+	return rating
+}
+
+// Expected returns the expected score of a player rated a against a player rated b
+func Expected(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// Update rates a game between two players, score is the score of a: 1 for a win, 0 for a loss and .5 for a draw
+func (t *EloTable) Update(a, b string, score float64) {
+	ra, rb := t.Player(a), t.Player(b)
+	expected := Expected(ra.Elo, rb.Elo)
+	ra.Elo += EloK * (score - expected)
+	rb.Elo -= EloK * (score - expected)
+	ra.Games++
+	rb.Games++
+	switch {
+	case score > .5:
+		ra.Wins++
+		rb.Losses++
+	case score < .5:
+		ra.Losses++
+		rb.Wins++
+	default:
+		ra.Draws++
+		rb.Draws++
+	}
+}
+
+// Ranking returns the ratings from the highest to the lowest
+func (t *EloTable) Ranking() []Rating {
+	ranking := make([]Rating, 0, len(t.Ratings))
+	for _, rating := range t.Ratings {
+		ranking = append(ranking, *rating)
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].Elo != ranking[j].Elo {
+			return ranking[i].Elo > ranking[j].Elo
+		}
+		return ranking[i].Name < ranking[j].Name
+	})
+	return ranking
+}
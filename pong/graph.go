@@ -0,0 +1,74 @@
+package pong
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Edge is a connection between two neurons
+type Edge struct {
+	From int
+	To   int
+	// Weight is the walk count of the connection
+	Weight float64
+}
+
+// Edges returns the connections of the network weighted by how often the walk of Iterate followed them
+func (n *Network) Edges() []Edge {
+	edges := make([]Edge, 0, len(n.Neurons)*n.Width)
+	for i, neuron := range n.Neurons {
+		for ii, connection := range neuron.Connections {
+			edges = append(edges, Edge{
+				From:   i,
+				To:     connection,
+				Weight: neuron.Vector[ii],
+			})
+		}
+	}
+	return edges
+}
+
+// Norm returns the euclidean norm of the embedding of a neuron
+func (n *Network) Norm(neuron int) float64 {
+	sum := 0.0
+	for _, value := range n.Neurons[neuron].Vector[n.Width:] {
+		sum += value * value
+	}
+	return math.Sqrt(sum)
+}
+
+// WriteDOT writes the network as a Graphviz directed graph
+func (n *Network) WriteDOT(output io.Writer) error {
+	writer := bufio.NewWriter(output)
+	fmt.Fprintln(writer, "digraph network {")
+	for i := range n.Neurons {
+		fmt.Fprintf(writer, "\t%d [norm=%g];\n", i, n.Norm(i))
+	}
+	for _, edge := range n.Edges() {
+		fmt.Fprintf(writer, "\t%d -> %d [weight=%g];\n", edge.From, edge.To, edge.Weight)
+	}
+	fmt.Fprintln(writer, "}")
+	return writer.Flush()
+}
+
+// WriteGraphML writes the network as a GraphML directed graph
+func (n *Network) WriteGraphML(output io.Writer) error {
+	writer := bufio.NewWriter(output)
+	fmt.Fprintln(writer, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(writer, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Fprintln(writer, `  <key id="norm" for="node" attr.name="norm" attr.type="double"/>`)
+	fmt.Fprintln(writer, `  <key id="weight" for="edge" attr.name="weight" attr.type="double"/>`)
+	fmt.Fprintln(writer, `  <graph id="network" edgedefault="directed">`)
+	for i := range n.Neurons {
+		fmt.Fprintf(writer, "    <node id=\"n%d\"><data key=\"norm\">%g</data></node>\n", i, n.Norm(i))
+	}
+	for i, edge := range n.Edges() {
+		fmt.Fprintf(writer, "    <edge id=\"e%d\" source=\"n%d\" target=\"n%d\"><data key=\"weight\">%g</data></edge>\n",
+			i, edge.From, edge.To, edge.Weight)
+	}
+	fmt.Fprintln(writer, "  </graph>")
+	fmt.Fprintln(writer, "</graphml>")
+	return writer.Flush()
+}
@@ -0,0 +1,195 @@
+package pong
+
+import "slices"
+
+const (
+	// LearningFrames is the default number of frames a walk stays eligible for a reward
+	LearningFrames = 60
+	// LearningDecay is the default decay of the eligibility of a walk each frame
+	LearningDecay = 0.95
+	// LearningRate is the default number of walk counts the most eligible connection gains for a reward of 1
+	LearningRate = 8
+	// LearningReadoutRate is the default share of the newest perception the readout of its side gains for a reward of 1
+	LearningReadoutRate = 1
+)
+
+// Learner is a controller that learns from rewards
+type Learner interface {
+	Controller
+	// Reward tells the controller how good its recent decisions were
+	Reward(reward float64)
+}
+
+// Rewards returns the reward of each player for a tick: a hit is worth 1 and a missed ball -1
+func Rewards(events Events) (float64, float64) {
+	return float64(events.Player1.Hits - events.Player2.Points),
+		float64(events.Player2.Hits - events.Player1.Points)
+}
+
+// Reward rewards the controllers that learn for the hits and misses of a tick
+func Reward(events Events, player1, player2 Controller) {
+	reward1, reward2 := Rewards(events)
+	if learner, ok := player1.(Learner); ok && reward1 != 0 {
+		learner.Reward(reward1)
+	}
+	if learner, ok := player2.(Learner); ok && reward2 != 0 {
+		learner.Reward(reward2)
+	}
+}
+
+// Eligibility keeps the walks and the perceptions of the last frames of a network so that a reward can be credited
+// to the connections that were behind the recent decisions
+type Eligibility struct {
+	// Frames is the number of frames a walk stays eligible for a reward
+	Frames int
+	// Decay is how much the eligibility of a walk decays each frame
+	Decay float64
+	// Rate is how many walk counts the most eligible connection gains for a reward of 1
+	Rate float64
+	// ReadoutRate is how much of the newest perception the readout of the side the decision took gains for a reward of 1
+	ReadoutRate float64
+	// traces are the visits of the walks of the last frames, traces[next] is the oldest
+	traces [][][]float64
+	// perceptions are the perceptions of the last frames and sides the side each frame's decision took
+	perceptions [][]float64
+	sides       []Action
+	next        int
+}
+
+// NewEligibility creates eligibility traces over frames frames
+func NewEligibility(frames int, decay, rate, readoutRate float64) *Eligibility {
+	return &Eligibility{
+		Frames:      frames,
+		Decay:       decay,
+		Rate:        rate,
+		ReadoutRate: readoutRate,
+	}
+}
+
+// Trace remembers the walk of the last iteration of the network with what it perceived and decided
+func (e *Eligibility) Trace(n *Network, perception []float64, side Action) {
+	if e.Frames < 1 {
+		return
+	}
+	if len(e.traces) < e.Frames {
+		trace := make([][]float64, len(n.Visits))
+		for i := range trace {
+			trace[i] = slices.Clone(n.Visits[i])
+		}
+		e.traces = append(e.traces, trace)
+		e.perceptions = append(e.perceptions, slices.Clone(perception))
+		e.sides = append(e.sides, side)
+		return
+	}
+	trace := e.traces[e.next]
+	for i := range trace {
+		copy(trace[i], n.Visits[i])
+	}
+	copy(e.perceptions[e.next], perception)
+	e.sides[e.next] = side
+	e.next = (e.next + 1) % e.Frames
+}
+
+// Reward strengthens the readout of the eligible decisions and the walk counts of the eligible connections
+// for a positive reward and weakens them for a negative one, a connection that is weakened to nothing is moved to another neuron
+func (e *Eligibility) Reward(n *Network, reward float64) {
+	if reward == 0 || len(e.traces) == 0 {
+		return
+	}
+	e.rewardReadout(n, reward)
+	eligibility := make([][]float64, len(n.Neurons))
+	for i := range eligibility {
+		eligibility[i] = make([]float64, n.Width)
+	}
+	// the newest trace is the most eligible
+	weight, highest := 1.0, 0.0
+	for k := range e.traces {
+		trace := e.traces[(e.next+len(e.traces)-1-k)%len(e.traces)]
+		for i := range trace {
+			for ii, visits := range trace[i] {
+				eligibility[i][ii] += weight * visits
+				highest = max(highest, eligibility[i][ii])
+			}
+		}
+		weight *= e.Decay
+	}
+	if highest == 0 {
+		return
+	}
+	for i := range n.Neurons {
+		neuron := &n.Neurons[i]
+		for ii := range neuron.Connections {
+			if eligibility[i][ii] == 0 {
+				continue
+			}
+			count := neuron.Vector[ii] + e.Rate*reward*eligibility[i][ii]/highest
+			if count < 1 {
+				n.rewire(i, ii)
+				continue
+			}
+			neuron.Vector[ii] = min(count, 128)
+		}
+	}
+}
+
+// rewardReadout adds the eligible perceptions to the embeddings of the action neurons of the side each decision took,
+// so that the readout leans towards the decisions that were rewarded and away from the ones that were punished
+func (e *Eligibility) rewardReadout(n *Network, reward float64) {
+	weight := e.ReadoutRate * reward
+	for k := range e.traces {
+		frame := (e.next + len(e.traces) - 1 - k) % len(e.traces)
+		for i := n.Inputs; i < n.Size(); i++ {
+			if e.sides[frame] != readoutSide(i) {
+				continue
+			}
+			embedding := n.Neurons[i].Vector[n.Width : n.Width+n.Embedding]
+			for ii, value := range e.perceptions[frame] {
+				embedding[ii] += weight * value
+			}
+		}
+		weight *= e.Decay
+	}
+}
+
+// rewire moves a connection of a neuron to a random neuron it isn't connected to yet
+func (n *Network) rewire(neuron, slot int) {
+	connections := n.Neurons[neuron].Connections
+	next := n.Rng.Intn(len(n.Neurons))
+	for next == neuron || slices.Contains(connections, next) {
+		next = n.Rng.Intn(len(n.Neurons))
+	}
+	n.Telemetry.Record(Entry{
+		Kind: RewireKind,
+		Value: Rewire{
+			Neuron: neuron,
+			Slot:   slot,
+			From:   connections[slot],
+			To:     next,
+		},
+	})
+	connections[slot] = next
+	n.Neurons[neuron].Vector[slot] = 1
+}
+
+// readoutSide returns the side an action neuron reads out for, the even neurons vote up and the odd ones down
+func readoutSide(neuron int) Action {
+	if neuron&1 == 0 {
+		return ActionUp
+	}
+	return ActionDown
+}
+
+// Readout is how much the action neurons lean up for a perception, the embedding of an action neuron
+// holds the connections to the perception it learned, it is 0 until the network is rewarded
+func (n *Network) Readout(perception []float64) float64 {
+	readout := 0.0
+	for i := n.Inputs; i < n.Size(); i++ {
+		lean := dot(n.Neurons[i].Vector[n.Width:n.Width+n.Embedding], perception)
+		if readoutSide(i) == ActionUp {
+			readout += lean
+		} else {
+			readout -= lean
+		}
+	}
+	return readout
+}
@@ -0,0 +1,41 @@
+package pong
+
+import "testing"
+
+func TestRewardReadout(t *testing.T) {
+	network, err := NewNetwork(1, DefaultNetworkConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	network.Iterate()
+	perception := make([]float64, network.Embedding)
+	for i := range perception {
+		perception[i] = float64(i%3-1) / float64(network.Embedding)
+	}
+	if readout := network.Readout(perception); readout != 0 {
+		t.Fatalf("the readout of a network that was never rewarded is %v", readout)
+	}
+
+	e := NewEligibility(LearningFrames, LearningDecay, LearningRate, LearningReadoutRate)
+	e.Trace(&network, perception, ActionUp)
+	e.Reward(&network, 1)
+	if readout := network.Readout(perception); readout <= 0 {
+		t.Fatalf("going up was rewarded but the readout is %v", readout)
+	}
+	e.Reward(&network, -2)
+	if readout := network.Readout(perception); readout >= 0 {
+		t.Fatalf("going up was punished more than it was rewarded but the readout is %v", readout)
+	}
+
+	down := NewEligibility(LearningFrames, LearningDecay, LearningRate, LearningReadoutRate)
+	fresh, err := NewNetwork(1, DefaultNetworkConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh.Iterate()
+	down.Trace(&fresh, perception, ActionDown)
+	down.Reward(&fresh, 1)
+	if readout := fresh.Readout(perception); readout >= 0 {
+		t.Fatalf("going down was rewarded but the readout is %v", readout)
+	}
+}
@@ -1,12 +1,10 @@
 package pong
 
 import (
-	"errors"
 	"fmt"
-	"io"
 	"math"
-	"os"
 	"runtime"
+	"sync"
 	"sync/atomic"
 )
 
@@ -87,28 +85,75 @@ func (s Set[T]) Named(name string) Matrix[T] {
 	return *s.ByName[name]
 }
 
-// MulT multiplies two matrices and computes the transpose
+const (
+	// mulTileBytes is the size of the tile of rows of m that MulT keeps in cache
+	mulTileBytes = 32 << 10
+	// mulParallel is the number of multiplications above which MulT uses goroutines
+	mulParallel = 1 << 16
+)
+
+// MulT multiplies two matrices and computes the transpose,
+// each value is always a dot product in the same order so the result is bit-identical
+// whether the multiplication is split across goroutines or not
 func (m Matrix[T]) MulT(n Matrix[T]) Matrix[T] {
 	if m.Cols != n.Cols {
 		panic(fmt.Errorf("%d != %d", m.Cols, n.Cols))
 	}
-	columns := m.Cols
 	o := Matrix[T]{
 		Size: Size{
 			Cols: m.Rows,
 			Rows: n.Rows,
 		},
-		Data: make([]T, 0, m.Rows*n.Rows),
+		Data: make([]T, m.Rows*n.Rows),
+	}
+	if m.Cols == 0 {
+		return o
+	}
+	tile := mulTileBytes / (m.Cols * dtype[T]().Width())
+	if tile < 1 {
+		tile = 1
+	}
+	cpus := runtime.GOMAXPROCS(0)
+	// wasm is single threaded and the game's matrices are too small for goroutines to pay off
+	if runtime.GOARCH == "wasm" || cpus == 1 || m.Rows*n.Rows*m.Cols < mulParallel {
+		mulT(m, n, o, tile, 0, n.Rows)
+		return o
+	}
+	// the rows of n are split in blocks that the goroutines take in turn
+	block := (n.Rows + 4*cpus - 1) / (4 * cpus)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range cpus {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				begin := int(next.Add(int64(block))) - block
+				if begin >= n.Rows {
+					return
+				}
+				mulT(m, n, o, tile, begin, min(begin+block, n.Rows))
+			}
+		}()
 	}
-	lenn, lenm := len(n.Data), len(m.Data)
-	for i := 0; i < lenn; i += columns {
-		nn := n.Data[i : i+columns]
-		for j := 0; j < lenm; j += columns {
-			mm := m.Data[j : j+columns]
-			o.Data = append(o.Data, dot(mm, nn))
+	wg.Wait()
+	return o
+}
+
+// mulT computes the rows begin to end of o = m×nᵀ, tile rows of m at a time
+// so that they stay in cache while the rows of n go through them
+func mulT[T Float](m, n, o Matrix[T], tile, begin, end int) {
+	columns := m.Cols
+	for j := 0; j < m.Rows; j += tile {
+		last := min(j+tile, m.Rows)
+		for i := begin; i < end; i++ {
+			nn := n.Data[i*columns : (i+1)*columns]
+			row := o.Data[i*m.Rows : (i+1)*m.Rows]
+			for k := j; k < last; k++ {
+				row[k] = dot(m.Data[k*columns:(k+1)*columns], nn)
+			}
 		}
 	}
-	return o
 }
 
 // Add adds two float64 matrices
@@ -301,93 +346,6 @@ func (m Matrix[T]) T() Matrix[T] {
 	return o
 }
 
-// Write writes the matrix to a file
-func (m Matrix[T]) Write(output *os.File) error {
-	switch data := any(m.Data).(type) {
-	case []float64:
-		buffer64 := make([]byte, 8)
-		for _, parameter := range data {
-			bits := math.Float64bits(parameter)
-			for i := range buffer64 {
-				buffer64[i] = byte((bits >> (8 * i)) & 0xFF)
-			}
-			n, err := output.Write(buffer64)
-			if err != nil {
-				return err
-			}
-			if n != len(buffer64) {
-				return errors.New("8 bytes should be been written")
-			}
-		}
-	case []float32:
-		buffer32 := make([]byte, 4)
-		for _, parameter := range data {
-			bits := math.Float32bits(parameter)
-			for i := range buffer32 {
-				buffer32[i] = byte((bits >> (8 * i)) & 0xFF)
-			}
-			n, err := output.Write(buffer32)
-			if err != nil {
-				return err
-			}
-			if n != len(buffer32) {
-				return errors.New("8 bytes should be been written")
-			}
-		}
-	}
-	return nil
-}
-
-// Read reads the matrix from a file
-func (m *Matrix[T]) Read(input *os.File) error {
-	switch any(m.Data).(type) {
-	case []float64:
-		buffer64 := make([]byte, 8)
-		for range m.Rows {
-			for range m.Cols {
-				n, err := input.Read(buffer64)
-				if err == io.EOF {
-					return err
-				} else if err != nil {
-					return err
-				}
-				if n != len(buffer64) {
-					return fmt.Errorf("not all bytes read: %d", n)
-				}
-				value := uint64(0)
-				for k := 0; k < 8; k++ {
-					value <<= 8
-					value |= uint64(buffer64[7-k])
-				}
-				m.Data = append(m.Data, T(math.Float64frombits(value)))
-			}
-		}
-	case []float32:
-		buffer32 := make([]byte, 4)
-		for range m.Rows {
-			for range m.Cols {
-				n, err := input.Read(buffer32)
-				if err == io.EOF {
-					return err
-				} else if err != nil {
-					return err
-				}
-				if n != len(buffer32) {
-					return fmt.Errorf("not all bytes read: %d", n)
-				}
-				value := uint32(0)
-				for k := 0; k < 4; k++ {
-					value <<= 8
-					value |= uint32(buffer32[3-k])
-				}
-				m.Data = append(m.Data, T(math.Float32frombits(value)))
-			}
-		}
-
-	}
-	return nil
-}
-
 func dot[T Float](x, y []T) (z T) {
 	for i := range x {
 		z += T(x[i] * y[i])
@@ -712,44 +670,81 @@ func PageRankMarkov[T Float](a float32, e int, seed uint32, adj Matrix[T]) Matri
 	return p
 }
 
-// Transformer implements transform inference
-func Transformer[T Float](set Set[T], inputs, outputs Matrix[T]) Matrix[T] {
-	itags := set.Named("itags")
-	if inputs.Rows != itags.Rows {
-		panic("rows should be the same")
-	}
-	in := NewMatrix[T](itags.Cols+inputs.Cols, inputs.Rows)
-	for i := 0; i < itags.Rows; i++ {
-		in.Data = append(in.Data, itags.Data[i*itags.Cols:(i+1)*itags.Cols]...)
-		in.Data = append(in.Data, inputs.Data[i*inputs.Cols:(i+1)*inputs.Cols]...)
-	}
-	otags := set.Named("otags")
-	if outputs.Rows != otags.Rows {
-		panic("rows should be the same")
-	}
-	out := NewMatrix[T](otags.Cols+outputs.Cols, outputs.Rows)
-	for i := 0; i < otags.Rows; i++ {
-		out.Data = append(out.Data, otags.Data[i*otags.Cols:(i+1)*otags.Cols]...)
-		out.Data = append(out.Data, outputs.Data[i*outputs.Cols:(i+1)*outputs.Cols]...)
-	}
-	embeddingIn := set.Named("lembeddingIn").MulT(in).Add(set.Named("bembeddingIn")).ReLu()
-	formIn := SelfAttention(set.Named("inQ").MulT(embeddingIn),
-		set.Named("inK").MulT(embeddingIn),
-		set.Named("inV").MulT(embeddingIn)).
-		Add(embeddingIn)
-	l1In := set.Named("l1In").MulT(formIn).Add(set.Named("b1In")).ReLu().Add(formIn)
-
-	embeddingOut := set.Named("lembeddingOut").MulT(out).Add(set.Named("bembeddingOut")).ReLu()
-	formOut := SelfAttention(set.Named("outQ1").MulT(embeddingOut),
-		set.Named("outK1").MulT(embeddingOut),
-		set.Named("outV1").MulT(embeddingOut)).
-		Add(embeddingOut)
-	formOut1 := SelfAttention(set.Named("outQ2").MulT(formOut),
-		set.Named("outK2").MulT(l1In),
-		set.Named("outV2").MulT(l1In)).
-		Add(formOut)
-	l1Out := set.Named("l1Out").MulT(formOut1).Add(set.Named("b1Out")).ReLu().Add(formOut1)
-	return set.Named("linear").MulT(l1Out).Softmax(1)
+// Transformer implements transform inference, it returns an error if a matrix is missing from the set
+// or if the shapes of the matrices don't fit
+func Transformer[T Float](set Set[T], inputs, outputs Matrix[T]) (Matrix[T], error) {
+	// the operations do nothing after the first error
+	var err error
+	named := func(name string) (m Matrix[T]) {
+		if err == nil {
+			m, err = set.Lookup(name)
+		}
+		return m
+	}
+	mulT := func(m, n Matrix[T]) (o Matrix[T]) {
+		if err == nil {
+			o, err = m.CheckedMulT(n)
+		}
+		return o
+	}
+	add := func(m, n Matrix[T]) (o Matrix[T]) {
+		if err == nil {
+			o, err = m.CheckedAdd(n)
+		}
+		return o
+	}
+	attention := func(Q, K, V Matrix[T]) (o Matrix[T]) {
+		if err == nil {
+			o, err = CheckedSelfAttention(Q, K, V)
+		}
+		return o
+	}
+	tag := func(tags, values Matrix[T]) (o Matrix[T]) {
+		if err != nil {
+			return o
+		}
+		if values.Rows != tags.Rows {
+			err = &ShapeError{Op: "tag", A: tags.Size, B: values.Size, Reason: "the rows should be the same"}
+			return o
+		}
+		for _, matrix := range []Matrix[T]{tags, values} {
+			if err = checkData("tag", matrix); err != nil {
+				return o
+			}
+		}
+		o = NewMatrix[T](tags.Cols+values.Cols, values.Rows)
+		for i := 0; i < tags.Rows; i++ {
+			o.Data = append(o.Data, tags.Data[i*tags.Cols:(i+1)*tags.Cols]...)
+			o.Data = append(o.Data, values.Data[i*values.Cols:(i+1)*values.Cols]...)
+		}
+		return o
+	}
+
+	in := tag(named("itags"), inputs)
+	out := tag(named("otags"), outputs)
+
+	embeddingIn := add(mulT(named("lembeddingIn"), in), named("bembeddingIn")).ReLu()
+	formIn := add(attention(mulT(named("inQ"), embeddingIn),
+		mulT(named("inK"), embeddingIn),
+		mulT(named("inV"), embeddingIn)),
+		embeddingIn)
+	l1In := add(add(mulT(named("l1In"), formIn), named("b1In")).ReLu(), formIn)
+
+	embeddingOut := add(mulT(named("lembeddingOut"), out), named("bembeddingOut")).ReLu()
+	formOut := add(attention(mulT(named("outQ1"), embeddingOut),
+		mulT(named("outK1"), embeddingOut),
+		mulT(named("outV1"), embeddingOut)),
+		embeddingOut)
+	formOut1 := add(attention(mulT(named("outQ2"), formOut),
+		mulT(named("outK2"), l1In),
+		mulT(named("outV2"), l1In)),
+		formOut)
+	l1Out := add(add(mulT(named("l1Out"), formOut1), named("b1Out")).ReLu(), formOut1)
+	result := mulT(named("linear"), l1Out)
+	if err != nil {
+		return Matrix[T]{}, fmt.Errorf("transformer: %w", err)
+	}
+	return result.Softmax(1), nil
 }
 
 // GramSchmidt performs the Gram-Schmidt process on the columns of a matrix
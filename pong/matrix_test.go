@@ -0,0 +1,78 @@
+package pong
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+// randomMatrix creates a matrix of normally distributed values
+func randomMatrix(cols, rows int, seed int64) Matrix[float64] {
+	rng := rand.New(rand.NewSource(seed))
+	m := NewMatrix(cols, rows, make([]float64, cols*rows)...)
+	for i := range m.Data {
+		m.Data[i] = rng.NormFloat64()
+	}
+	return m
+}
+
+// mulTSize is the size of a multiplication m×nᵀ, m is Cols×Rows and n is Cols×Vectors
+type mulTSize struct {
+	Name    string
+	Cols    int
+	Rows    int
+	Vectors int
+}
+
+func (s mulTSize) String() string {
+	return fmt.Sprintf("%s %dx%d %dx%d", s.Name, s.Cols, s.Rows, s.Cols, s.Vectors)
+}
+
+func benchmarkMulT(b *testing.B, sizes []mulTSize) {
+	for _, s := range sizes {
+		b.Run(s.String(), func(b *testing.B) {
+			m, n := randomMatrix(s.Cols, s.Rows, 1), randomMatrix(s.Cols, s.Vectors, 2)
+			for range b.N {
+				m.MulT(n)
+			}
+		})
+	}
+}
+
+// BenchmarkMulT times the multiplications of the network of the game, they are too small to be split across goroutines
+func BenchmarkMulT(b *testing.B) {
+	config := DefaultNetworkConfig()
+	size, width := config.Size(), config.Width+config.Embedding
+	benchmarkMulT(b, []mulTSize{
+		{"iterate project", width, width, config.Width + 2},
+		{"iterate similarity", width, config.Width + 2, config.Width + 2},
+		{"decide project", config.Width, config.Width, size},
+		{"decide similarity", config.Width, size, size},
+	})
+}
+
+// BenchmarkMulTParallel times multiplications large enough to be split across goroutines,
+// run it with -cpu 1,4 to compare one thread with several
+func BenchmarkMulTParallel(b *testing.B) {
+	benchmarkMulT(b, []mulTSize{
+		{"transformer", InputSize, InputSize, InputSize},
+		{"wide", 1024, 256, 64},
+	})
+}
+
+func TestMulTParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	// odd sizes so that the tiles and the blocks of the goroutines don't divide the rows
+	m, n := randomMatrix(67, 300, 1), randomMatrix(67, 257, 2)
+	if m.Rows*n.Rows*m.Cols < mulParallel {
+		t.Fatal("the multiplication is too small to be split across goroutines")
+	}
+	serial := Matrix[float64]{
+		Size: Size{Cols: m.Rows, Rows: n.Rows},
+		Data: make([]float64, m.Rows*n.Rows),
+	}
+	mulT(m, n, serial, mulTileBytes/(m.Cols*Float64.Width()), 0, n.Rows)
+	parallel := m.MulT(n)
+	equalMatrices(t, parallel, serial)
+}
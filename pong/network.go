@@ -0,0 +1,395 @@
+package pong
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// NetworkVersion is the version of the network file format
+const NetworkVersion = 3
+
+var networkMagic = [4]byte{'P', 'N', 'E', 'T'}
+
+// maxNetworkValues is the largest number of connections and vector values of a network that is read,
+// the neurons of a corrupt header would not fit in memory otherwise
+const maxNetworkValues = 1 << 24
+
+type networkHeader struct {
+	Magic     [4]byte
+	Version   uint16
+	Inputs    uint32
+	Actions   uint32
+	Width     uint32
+	Embedding uint32
+	Seed      int64
+	State     uint64
+}
+
+// Neuron a neuron
+type Neuron struct {
+	Connections []int
+	Vector      []float64
+}
+
+// NetworkConfig is the shape of a network
+type NetworkConfig struct {
+	// Inputs is the number of neurons the perception is embedded into in turn
+	Inputs int
+	// Actions is the number of neurons after the input neurons that only take part in the decisions
+	Actions int
+	// Width is the number of connections of each neuron
+	Width int
+	// Embedding is the size of the embedding of each neuron
+	Embedding int
+}
+
+// DefaultNetworkConfig returns the shape of the network players
+func DefaultNetworkConfig() NetworkConfig {
+	return NetworkConfig{
+		Inputs:    6,
+		Actions:   2,
+		Width:     4,
+		Embedding: 32,
+	}
+}
+
+// Size is the number of neurons
+func (c NetworkConfig) Size() int {
+	return c.Inputs + c.Actions
+}
+
+// Validate checks that a network of this shape can be built and iterated
+func (c NetworkConfig) Validate() error {
+	var errs []error
+	if c.Inputs < 1 {
+		errs = append(errs, fmt.Errorf("inputs should be at least 1: %d", c.Inputs))
+	}
+	if c.Actions < 0 {
+		errs = append(errs, fmt.Errorf("actions should not be negative: %d", c.Actions))
+	}
+	if c.Width < 1 {
+		errs = append(errs, fmt.Errorf("width should be at least 1: %d", c.Width))
+	}
+	if c.Embedding < 1 {
+		errs = append(errs, fmt.Errorf("embedding should be at least 1: %d", c.Embedding))
+	}
+	// a neuron needs a neuron it isn't connected to yet to rewire a connection to
+	if c.Size() < c.Width+2 {
+		errs = append(errs, fmt.Errorf("there should be at least width+2 = %d neurons: %d", c.Width+2, c.Size()))
+	}
+	return errors.Join(errs...)
+}
+
+// Network is a neural network
+type Network struct {
+	NetworkConfig
+	Rng *rand.Rand
+	// Source is the source of Rng, it remembers how far Rng has gone so it can be saved
+	Source  *Source
+	Neurons []Neuron
+	// Telemetry records the rewiring and the walk counts
+	Telemetry Telemetry
+	// Visits are how many times the walk of the last iteration followed each connection of each neuron
+	Visits [][]float64
+}
+
+// NewNetwork creates a new neural network
+func NewNetwork(seed int64, config NetworkConfig) (Network, error) {
+	err := config.Validate()
+	if err != nil {
+		return Network{}, err
+	}
+	width, embedding := config.Width, config.Embedding
+	neurons := make([]Neuron, config.Size())
+	for i := range neurons {
+		neurons[i].Connections = make([]int, width)
+		neurons[i].Vector = make([]float64, width+embedding)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	for i := range neurons {
+		for ii := range neurons[i].Connections {
+			next := rng.Intn(len(neurons))
+			for next == i {
+				next = rng.Intn(len(neurons))
+			}
+			neurons[i].Connections[ii] = next
+		}
+		for ii := range neurons[i].Vector[:width] {
+			neurons[i].Vector[ii] = float64(rng.Intn(256))
+		}
+	}
+	source := NewSource(seed)
+	return Network{
+		NetworkConfig: config,
+		Rng:           rand.New(source),
+		Source:        source,
+		Neurons:       neurons,
+		Telemetry:     NoTelemetry{},
+	}, nil
+}
+
+// NeuralMode neural mode
+func (n *Network) Iterate() {
+	rng := n.Rng
+	neurons := n.Neurons
+	width := n.Width
+	embedding := n.Embedding
+	{
+		for i := range neurons {
+			next := rng.Intn(len(neurons))
+			for next == i || slices.Contains(neurons[i].Connections[:], next) {
+				next = rng.Intn(len(neurons))
+			}
+			vectors := make([]*Vector[Neuron], width+2)
+			index := 0
+			for ii := range neurons[i].Connections {
+				vector := Vector[Neuron]{}
+				vector.Meta = neurons[neurons[i].Connections[ii]]
+				vector.Vector = neurons[neurons[i].Connections[ii]].Vector
+				vectors[ii] = &vector
+				index++
+			}
+			{
+				a := Vector[Neuron]{}
+				a.Meta = neurons[next]
+				a.Vector = neurons[next].Vector
+				vectors[index] = &a
+				index++
+			}
+			{
+				a := Vector[Neuron]{}
+				a.Meta = neurons[i]
+				a.Vector = neurons[i].Vector
+				vectors[index] = &a
+				index++
+			}
+			config := Config{
+				Iterations: 16,
+				Size:       width + embedding,
+				Divider:    1,
+			}
+			MorpheusFast(rng.Int63(), config, vectors)
+			{
+				max, index := 0.0, 0
+				for i := range vectors[:len(vectors)-1] {
+					if vectors[i].Stddev > max {
+						max, index = vectors[i].Stddev, i
+					}
+				}
+				if index != len(vectors)-2 {
+					n.Telemetry.Record(Entry{
+						Kind: RewireKind,
+						Value: Rewire{
+							Neuron: i,
+							Slot:   index,
+							From:   neurons[i].Connections[index],
+							To:     next,
+						},
+					})
+					neurons[i].Connections[index] = next
+				}
+			}
+		}
+		if len(n.Visits) != len(neurons) {
+			n.Visits = make([][]float64, len(neurons))
+			for i := range n.Visits {
+				n.Visits[i] = make([]float64, width)
+			}
+		}
+		for i := range n.Visits {
+			clear(n.Visits[i])
+		}
+		previous, neuron := 0, 0
+		for range 1024 {
+			for i := range neurons[neuron].Vector[:width] {
+				if neurons[neuron].Vector[i] > 128 {
+					for i, value := range neurons[neuron].Vector[:width] {
+						neurons[neuron].Vector[i] = math.Round(value / 2)
+					}
+					break
+				}
+			}
+			sum := 0.0
+			for _, value := range neurons[neuron].Vector[:width] {
+				sum += value
+			}
+			index := 0
+			if int(sum) < 1 {
+				// nothing to go by, any connection will do
+				index = rng.Intn(width)
+			} else {
+				total, selected := 0.0, float64(rng.Intn(int(sum)))
+				for i, value := range neurons[neuron].Vector[:width] {
+					total += value
+					if selected < total {
+						index = i
+						break
+					}
+				}
+			}
+			for i, value := range neurons[neuron].Connections {
+				if value == previous {
+					neurons[neuron].Vector[i]++
+					break
+				}
+			}
+			n.Visits[neuron][index]++
+			previous, neuron = neuron, neurons[neuron].Connections[index]
+		}
+		walk := Walk{
+			Counts: make([][]float64, len(neurons)),
+		}
+		for i := range neurons {
+			walk.Counts[i] = slices.Clone(neurons[i].Vector[:width])
+		}
+		n.Telemetry.Record(Entry{
+			Kind:  WalkKind,
+			Value: walk,
+		})
+	}
+}
+
+// Source is a SplitMix64 random source, its whole state is one number so that it can be saved and restored at once
+type Source struct {
+	seed  int64
+	state uint64
+}
+
+// NewSource creates a source seeded with seed
+func NewSource(seed int64) *Source {
+	s := &Source{}
+	s.Seed(seed)
+	return s
+}
+
+// Uint64 returns a 64 bit integer
+func (s *Source) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+// Int63 returns a non-negative 63 bit integer
+func (s *Source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Seed reseeds the source
+func (s *Source) Seed(seed int64) {
+	s.seed, s.state = seed, uint64(seed)
+}
+
+// State returns the seed of the source and its current state
+func (s *Source) State() (seed int64, state uint64) {
+	return s.seed, s.state
+}
+
+// Restore puts the source back in a state returned by State
+func (s *Source) Restore(seed int64, state uint64) {
+	s.seed, s.state = seed, state
+}
+
+// Write writes the network, each neuron's connections are followed by its vector as little-endian floats
+func (n *Network) Write(output io.Writer) error {
+	if n.Source == nil {
+		return errors.New("the random source of the network can't be saved")
+	}
+	seed, state := n.Source.State()
+	header := networkHeader{
+		Magic:     networkMagic,
+		Version:   NetworkVersion,
+		Inputs:    uint32(n.Inputs),
+		Actions:   uint32(n.Actions),
+		Width:     uint32(n.Width),
+		Embedding: uint32(n.Embedding),
+		Seed:      seed,
+		State:     state,
+	}
+	err := binary.Write(output, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	connections := make([]uint32, n.Width)
+	for _, neuron := range n.Neurons {
+		for i, connection := range neuron.Connections {
+			connections[i] = uint32(connection)
+		}
+		err = binary.Write(output, binary.LittleEndian, connections)
+		if err != nil {
+			return err
+		}
+		err = writeData(output, neuron.Vector)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadNetwork reads a network written by Network.Write
+func ReadNetwork(input io.Reader) (Network, error) {
+	header := networkHeader{}
+	err := binary.Read(input, binary.LittleEndian, &header)
+	if err != nil {
+		return Network{}, err
+	}
+	if header.Magic != networkMagic {
+		return Network{}, errors.New("not a network file")
+	}
+	// version 2 saved how many numbers the random source had drawn instead of its state
+	if header.Version != NetworkVersion {
+		return Network{}, fmt.Errorf("unsupported network version: %d", header.Version)
+	}
+	config := NetworkConfig{
+		Inputs:    int(header.Inputs),
+		Actions:   int(header.Actions),
+		Width:     int(header.Width),
+		Embedding: int(header.Embedding),
+	}
+	err = config.Validate()
+	if err != nil {
+		return Network{}, err
+	}
+	width, embedding, size := config.Width, config.Embedding, config.Size()
+	if uint64(size)*uint64(width+embedding) > maxNetworkValues {
+		return Network{}, fmt.Errorf("the network is too large: %d neurons of %d connections and %d embedding values",
+			size, width, embedding)
+	}
+	// the neurons are added as they are read so that a truncated file fails before everything is allocated
+	neurons := make([]Neuron, 0, min(size, readChunk))
+	connections := make([]uint32, width)
+	for i := range size {
+		err = binary.Read(input, binary.LittleEndian, connections)
+		if err != nil {
+			return Network{}, err
+		}
+		neuron := Neuron{Connections: make([]int, width)}
+		for ii, connection := range connections {
+			if connection >= uint32(size) || connection == uint32(i) {
+				return Network{}, fmt.Errorf("invalid connection of neuron %d: %d", i, connection)
+			}
+			neuron.Connections[ii] = int(connection)
+		}
+		neuron.Vector, err = readData[float64, float64](input, width+embedding)
+		if err != nil {
+			return Network{}, err
+		}
+		neurons = append(neurons, neuron)
+	}
+	source := NewSource(header.Seed)
+	source.Restore(header.Seed, header.State)
+	return Network{
+		NetworkConfig: config,
+		Rng:           rand.New(source),
+		Source:        source,
+		Neurons:       neurons,
+		Telemetry:     NoTelemetry{},
+	}, nil
+}
@@ -0,0 +1,97 @@
+package pong
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestNetworkRoundTrip(t *testing.T) {
+	n, err := NewNetwork(7, DefaultNetworkConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the network is saved after it rewired and drew from its random source
+	for range 3 {
+		n.Iterate()
+	}
+	output := bytes.Buffer{}
+	err = n.Write(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadNetwork(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.NetworkConfig != n.NetworkConfig {
+		t.Fatalf("the shape changed: %+v, saved %+v", read.NetworkConfig, n.NetworkConfig)
+	}
+	if !reflect.DeepEqual(read.Neurons, n.Neurons) {
+		t.Fatal("the neurons changed")
+	}
+
+	// the restored source goes on where the saved one stopped, so both networks iterate the same way
+	n.Iterate()
+	read.Iterate()
+	if !reflect.DeepEqual(read.Neurons, n.Neurons) {
+		t.Fatal("the next iteration of the restored network is different")
+	}
+	if read.Rng.Int63() != n.Rng.Int63() {
+		t.Fatal("the random source of the restored network is different")
+	}
+}
+
+func TestReadNetworkErrors(t *testing.T) {
+	n, err := NewNetwork(1, DefaultNetworkConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := bytes.Buffer{}
+	err = n.Write(&saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := func(inputs, width, embedding uint32) []byte {
+		output := bytes.Buffer{}
+		err := binary.Write(&output, binary.LittleEndian, networkHeader{
+			Magic:     networkMagic,
+			Version:   NetworkVersion,
+			Inputs:    inputs,
+			Actions:   2,
+			Width:     width,
+			Embedding: embedding,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output.Bytes()
+	}
+
+	tests := []struct {
+		name  string
+		input []byte
+		err   error
+	}{
+		{"too many neurons", header(1<<31, 4, 32), nil},
+		{"too large embeddings", header(6, 4, 1<<30), nil},
+		{"invalid shape", header(6, 0, 32), nil},
+		{"truncated header", saved.Bytes()[:20], io.ErrUnexpectedEOF},
+		{"truncated neurons", saved.Bytes()[:saved.Len()-3], io.ErrUnexpectedEOF},
+		{"not a network", []byte("PMAT and more bytes than a header has....."), nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadNetwork(bytes.NewReader(test.input))
+			if err == nil {
+				t.Fatal("the network was read")
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Fatalf("the error should be %v: %v", test.err, err)
+			}
+		})
+	}
+}
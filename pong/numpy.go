@@ -0,0 +1,210 @@
+package pong
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var npyMagic = []byte("\x93NUMPY")
+
+// npyAlign is the alignment of the data of a .npy file, as written by numpy
+const npyAlign = 64
+
+// npyDescr returns the numpy dtype of the values of a matrix
+func npyDescr[T Float]() string {
+	if dtype[T]() == Float32 {
+		return "<f4"
+	}
+	return "<f8"
+}
+
+// WriteNpy writes the matrix as a numpy .npy file of shape (rows, cols)
+func (m Matrix[T]) WriteNpy(output io.Writer) error {
+	if len(m.Data) != m.Cols*m.Rows {
+		return fmt.Errorf("%d values don't fit a %dx%d matrix", len(m.Data), m.Cols, m.Rows)
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%d, %d), }", npyDescr[T](), m.Rows, m.Cols)
+	// the header ends with a new line and is padded with spaces so that the data is aligned
+	padding := npyAlign - (len(npyMagic)+4+len(header)+1)%npyAlign
+	if padding == npyAlign {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+	if len(header) > 0xFFFF {
+		return fmt.Errorf("the .npy header is too long: %d", len(header))
+	}
+	prefix := append(append([]byte{}, npyMagic...), 1, 0, 0, 0)
+	binary.LittleEndian.PutUint16(prefix[len(npyMagic)+2:], uint16(len(header)))
+	_, err := output.Write(prefix)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(output, header)
+	if err != nil {
+		return err
+	}
+	return writeData(output, m.Data)
+}
+
+var (
+	npyDescrPattern   = regexp.MustCompile(`'descr'\s*:\s*'([<>|=])f([48])'`)
+	npyFortranPattern = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShapePattern   = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// ReadNpy reads a numpy .npy file of float32 or float64 values into a matrix,
+// the values are converted to the type of the matrix and all the dimensions but the last one are rows
+func ReadNpy[T Float](input io.Reader) (Matrix[T], error) {
+	prefix := make([]byte, len(npyMagic)+2)
+	_, err := io.ReadFull(input, prefix)
+	if err != nil {
+		return Matrix[T]{}, err
+	}
+	if !bytes.Equal(prefix[:len(npyMagic)], npyMagic) {
+		return Matrix[T]{}, errors.New("not a .npy file")
+	}
+	length := 0
+	switch major := prefix[len(npyMagic)]; major {
+	case 1:
+		var n uint16
+		err = binary.Read(input, binary.LittleEndian, &n)
+		length = int(n)
+	case 2, 3:
+		var n uint32
+		err = binary.Read(input, binary.LittleEndian, &n)
+		length = int(n)
+	default:
+		return Matrix[T]{}, fmt.Errorf("unsupported .npy version: %d", major)
+	}
+	if err != nil {
+		return Matrix[T]{}, err
+	}
+	header := make([]byte, length)
+	_, err = io.ReadFull(input, header)
+	if err != nil {
+		return Matrix[T]{}, err
+	}
+
+	descr := npyDescrPattern.FindSubmatch(header)
+	if descr == nil {
+		return Matrix[T]{}, fmt.Errorf("unsupported .npy dtype, it should be float32 or float64: %s", header)
+	}
+	fortran := npyFortranPattern.FindSubmatch(header)
+	if fortran == nil || string(fortran[1]) != "False" {
+		return Matrix[T]{}, errors.New("unsupported .npy fortran order")
+	}
+	shape := npyShapePattern.FindSubmatch(header)
+	if shape == nil {
+		return Matrix[T]{}, fmt.Errorf("no shape in the .npy header: %s", header)
+	}
+	var dimensions []int
+	for _, dimension := range strings.Split(string(shape[1]), ",") {
+		dimension = strings.TrimSpace(dimension)
+		if dimension == "" {
+			continue
+		}
+		n, err := strconv.Atoi(dimension)
+		if err != nil {
+			return Matrix[T]{}, fmt.Errorf("invalid .npy shape: %s", shape[1])
+		}
+		dimensions = append(dimensions, n)
+	}
+	size, err := shapeSize(dimensions)
+	if err != nil {
+		return Matrix[T]{}, err
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if descr[1][0] == '>' {
+		order = binary.BigEndian
+	}
+	m := Matrix[T]{Size: size}
+	if descr[2][0] == '4' {
+		m.Data, err = readOrdered[float32, T](input, order, size.Cols*size.Rows)
+	} else {
+		m.Data, err = readOrdered[float64, T](input, order, size.Cols*size.Rows)
+	}
+	if err != nil {
+		return Matrix[T]{}, err
+	}
+	return m, nil
+}
+
+// shapeSize returns the size of a matrix of the given shape, all the dimensions but the last one are rows
+func shapeSize(shape []int) (Size, error) {
+	size := Size{Cols: 1, Rows: 1}
+	for i, dimension := range shape {
+		if dimension < 0 {
+			return Size{}, fmt.Errorf("invalid shape: %v", shape)
+		}
+		if i == len(shape)-1 {
+			size.Cols = dimension
+		} else {
+			size.Rows *= dimension
+		}
+	}
+	if uint64(size.Cols)*uint64(size.Rows) > maxTensorValues {
+		return Size{}, fmt.Errorf("shape is too large: %v", shape)
+	}
+	return size, nil
+}
+
+// WriteNpz writes the named matrices of the set as a numpy .npz archive of .npy files, in the order of the sizes
+func (s Set[T]) WriteNpz(output io.Writer) error {
+	archive := zip.NewWriter(output)
+	matrices, err := s.ordered()
+	if err != nil {
+		return err
+	}
+	for i, matrix := range matrices {
+		size := s.Sizes[i]
+		// numpy.savez stores the arrays without compression
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:   size.Name + ".npy",
+			Method: zip.Store,
+		})
+		if err != nil {
+			return err
+		}
+		err = matrix.WriteNpy(file)
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// ReadNpz reads a numpy .npz archive into a set in the order of the archive, every file should be a .npy file
+func ReadNpz[T Float](input io.ReaderAt, size int64) (Set[T], error) {
+	archive, err := zip.NewReader(input, size)
+	if err != nil {
+		return Set[T]{}, err
+	}
+	matrices := make([]Matrix[T], 0, len(archive.File))
+	for _, file := range archive.File {
+		name, ok := strings.CutSuffix(file.Name, ".npy")
+		if !ok {
+			return Set[T]{}, fmt.Errorf("not a .npy file in the archive: %s", file.Name)
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return Set[T]{}, err
+		}
+		matrix, err := ReadNpy[T](bufio.NewReader(reader))
+		reader.Close()
+		if err != nil {
+			return Set[T]{}, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		matrix.Name = name
+		matrices = append(matrices, matrix)
+	}
+	return newSet(matrices)
+}
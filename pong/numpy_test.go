@@ -0,0 +1,144 @@
+package pong
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenWeights and goldenBias are the matrices of the golden files in testdata,
+// the .npy and .safetensors files were written from the formats independently of the writers
+// and the set_*.npz files were written by WriteNpz and checked against the .npy files with Python's zipfile,
+// the savez_*.npz files were written like numpy.savez does, with the zip64 headers of zipfile.ZipFile.open(force_zip64=True)
+func goldenWeights[T Float]() Matrix[T] {
+	m := NewMatrix[T](3, 2, 1, -2.5, 3.25, 0, .001, 100)
+	m.Name = "weights"
+	return m
+}
+
+func goldenBias[T Float]() Matrix[T] {
+	m := NewMatrix[T](3, 1, .5, -.5, 2)
+	m.Name = "bias"
+	return m
+}
+
+// goldenSet is the set of the golden files in testdata
+func goldenSet[T Float](t *testing.T) Set[T] {
+	set, err := newSet([]Matrix[T]{goldenWeights[T](), goldenBias[T]()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+// golden compares output with the golden file name in testdata, or rewrites the file with -update
+func golden(t *testing.T, name string, output []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		err := os.WriteFile(path, output, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, expected) {
+		t.Fatalf("the output doesn't match %s:\n%q\n%q", path, output, expected)
+	}
+}
+
+// equalMatrices checks that two matrices have the same name, shape and values
+func equalMatrices[T Float](t *testing.T, m, n Matrix[T]) {
+	t.Helper()
+	if m.Size != n.Size {
+		t.Fatalf("the sizes are different: %s and %s", shape(m.Size), shape(n.Size))
+	}
+	for i := range m.Data {
+		if m.Data[i] != n.Data[i] {
+			t.Fatalf("%q: the values are different at %d: %v and %v", m.Name, i, m.Data[i], n.Data[i])
+		}
+	}
+}
+
+// equalSets checks that two sets have the same matrices in the same order
+func equalSets[T Float](t *testing.T, s, u Set[T]) {
+	t.Helper()
+	if len(s.ByIndex) != len(u.ByIndex) {
+		t.Fatalf("the sets have %d and %d matrices", len(s.ByIndex), len(u.ByIndex))
+	}
+	for i := range s.ByIndex {
+		equalMatrices(t, s.ByIndex[i], u.ByIndex[i])
+	}
+}
+
+func TestNpy(t *testing.T) {
+	t.Run("f32", func(t *testing.T) { testNpy[float32](t, "f32") })
+	t.Run("f64", func(t *testing.T) { testNpy[float64](t, "f64") })
+}
+
+func testNpy[T Float](t *testing.T, suffix string) {
+	for _, m := range []Matrix[T]{goldenWeights[T](), goldenBias[T]()} {
+		output := bytes.Buffer{}
+		err := m.WriteNpy(&output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := 10 + int(output.Bytes()[8]) + int(output.Bytes()[9])<<8
+		if header%npyAlign != 0 {
+			t.Errorf("%q: the data starts at %d, it isn't aligned to %d bytes", m.Name, header, npyAlign)
+		}
+		golden(t, m.Name+"_"+suffix+".npy", output.Bytes())
+
+		read, err := ReadNpy[T](&output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read.Name = m.Name
+		equalMatrices(t, read, m)
+	}
+}
+
+func TestNpz(t *testing.T) {
+	t.Run("f32", func(t *testing.T) { testNpz[float32](t, "f32") })
+	t.Run("f64", func(t *testing.T) { testNpz[float64](t, "f64") })
+}
+
+func testNpz[T Float](t *testing.T, suffix string) {
+	set := goldenSet[T](t)
+	output := bytes.Buffer{}
+	err := set.WriteNpz(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "set_"+suffix+".npz", output.Bytes())
+
+	read, err := ReadNpz[T](bytes.NewReader(output.Bytes()), int64(output.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	equalSets(t, read, set)
+}
+
+func TestReadSavez(t *testing.T) {
+	t.Run("f32", func(t *testing.T) { testReadSavez[float32](t, "f32") })
+	t.Run("f64", func(t *testing.T) { testReadSavez[float64](t, "f64") })
+}
+
+func testReadSavez[T Float](t *testing.T, suffix string) {
+	input, err := os.ReadFile(filepath.Join("testdata", "savez_"+suffix+".npz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadNpz[T](bytes.NewReader(input), int64(len(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	equalSets(t, read, goldenSet[T](t))
+}
@@ -0,0 +1,94 @@
+package pong
+
+import (
+	"image"
+	"math/rand"
+)
+
+// Projection is a sparse random projection, each entry is +1 or -1 with a probability of 1/6 and 0 otherwise
+type Projection struct {
+	Rows int
+	Cols int
+	// Plus and Minus are the columns of the +1 and -1 entries of each row
+	Plus  [][]int32
+	Minus [][]int32
+}
+
+// NewProjection creates a projection of cols inputs to rows outputs
+func NewProjection(seed int64, rows, cols int) *Projection {
+	rng := rand.New(rand.NewSource(seed))
+	p := &Projection{
+		Rows:  rows,
+		Cols:  cols,
+		Plus:  make([][]int32, rows),
+		Minus: make([][]int32, rows),
+	}
+	for i := range rows {
+		plus, minus := make([]int32, 0, cols/6), make([]int32, 0, cols/6)
+		for ii := range cols {
+			x := rng.Intn(6)
+			if x == 0 {
+				plus = append(plus, int32(ii))
+			} else if x == 1 {
+				minus = append(minus, int32(ii))
+			}
+		}
+		p.Plus[i], p.Minus[i] = plus, minus
+	}
+	return p
+}
+
+// Apply projects input into output
+func (p *Projection) Apply(input, output []float64) {
+	for i := range p.Rows {
+		sum := 0.0
+		for _, ii := range p.Plus[i] {
+			sum += input[ii]
+		}
+		for _, ii := range p.Minus[i] {
+			sum -= input[ii]
+		}
+		output[i] = sum
+	}
+}
+
+// Grayscale samples one pixel of each factor x factor block of the frame into gray levels,
+// dst is reused if it is large enough
+func Grayscale(frame image.Image, factor int, dst []float64) []float64 {
+	bounds := frame.Bounds()
+	width, height := bounds.Dx()/factor, bounds.Dy()/factor
+	if cap(dst) < width*height {
+		dst = make([]float64, width*height)
+	}
+	dst = dst[:width*height]
+	offset := factor / 2
+	switch frame := frame.(type) {
+	case *image.Gray:
+		for y := range height {
+			for x := range width {
+				dst[y*width+x] = float64(frame.GrayAt(bounds.Min.X+x*factor+offset, bounds.Min.Y+y*factor+offset).Y)
+			}
+		}
+	case *image.RGBA:
+		for y := range height {
+			for x := range width {
+				i := frame.PixOffset(bounds.Min.X+x*factor+offset, bounds.Min.Y+y*factor+offset)
+				pix := frame.Pix[i : i+3 : i+3]
+				dst[y*width+x] = gray(uint32(pix[0])*0x101, uint32(pix[1])*0x101, uint32(pix[2])*0x101)
+			}
+		}
+	default:
+		for y := range height {
+			for x := range width {
+				r, g, b, _ := frame.At(bounds.Min.X+x*factor+offset, bounds.Min.Y+y*factor+offset).RGBA()
+				dst[y*width+x] = gray(r, g, b)
+			}
+		}
+	}
+	return dst
+}
+
+// gray converts 16 bit color channels to an 8 bit gray level the same way as color.GrayModel
+func gray(r, g, b uint32) float64 {
+	return float64((19595*r + 38470*g + 7471*b + 1<<15) >> 24)
+}
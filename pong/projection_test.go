@@ -0,0 +1,103 @@
+package pong
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// embedPerPixel is how frames were embedded before the projection: every pixel is converted to gray
+// and the random entries are drawn again for every embedding dimension of every frame
+func embedPerPixel(frame image.Image, rng *rand.Rand, embedding []float64) {
+	bounds := frame.Bounds()
+	for i := range embedding {
+		sum := 0.0
+		for h := bounds.Min.Y; h < bounds.Max.Y; h++ {
+			for w := bounds.Min.X; w < bounds.Max.X; w++ {
+				grayPixel := color.GrayModel.Convert(frame.At(w, h)).(color.Gray)
+				x := rng.Intn(6)
+				if x == 0 {
+					sum += float64(grayPixel.Y)
+				} else if x == 1 {
+					sum -= float64(grayPixel.Y)
+				}
+			}
+		}
+		embedding[i] = sum
+	}
+}
+
+// benchmarkFrame returns a frame of the size of the game with a ball and two paddles
+func benchmarkFrame() *image.RGBA {
+	frame := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	for _, r := range []image.Rectangle{
+		image.Rect(50, 250, 70, 350),
+		image.Rect(730, 250, 750, 350),
+		image.Rect(390, 290, 410, 310),
+	} {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				frame.Set(x, y, color.White)
+			}
+		}
+	}
+	return frame
+}
+
+func TestProjectionMatchesPerPixel(t *testing.T) {
+	// a small frame of random colors so that every gray level conversion is exercised
+	rng := rand.New(rand.NewSource(2))
+	frame := image.NewRGBA(image.Rect(0, 0, 160, 120))
+	rng.Read(frame.Pix)
+	rows := DefaultNetworkConfig().Embedding
+	pixels := Grayscale(frame, 1, nil)
+	projected, expected := make([]float64, rows), make([]float64, rows)
+	NewProjection(1, rows, len(pixels)).Apply(pixels, projected)
+	embedPerPixel(frame, rand.New(rand.NewSource(1)), expected)
+	for i := range expected {
+		if projected[i] != expected[i] {
+			t.Fatalf("embedding %d is %v, it was %v when embedding per pixel", i, projected[i], expected[i])
+		}
+	}
+}
+
+func TestProjectionDistribution(t *testing.T) {
+	const rows, cols = 32, 30000
+	p := NewProjection(1, rows, cols)
+	plus, minus := 0, 0
+	for i := range rows {
+		plus += len(p.Plus[i])
+		minus += len(p.Minus[i])
+	}
+	for _, entries := range []struct {
+		name  string
+		count int
+	}{{"+1", plus}, {"-1", minus}} {
+		share := float64(entries.count) / (rows * cols)
+		if share < 1./6-.005 || share > 1./6+.005 {
+			t.Errorf("%.4f of the entries are %s, it should be about 1/6", share, entries.name)
+		}
+	}
+}
+
+func BenchmarkEmbed(b *testing.B) {
+	frame := benchmarkFrame()
+	embedding := make([]float64, DefaultNetworkConfig().Embedding)
+	b.Run("projection", func(b *testing.B) {
+		c, err := NewNetworkController(1, PixelPerception, DefaultNetworkConfig())
+		if err != nil {
+			b.Fatal(err)
+		}
+		c.embed(Observation{Frame: frame}, embedding)
+		b.ResetTimer()
+		for range b.N {
+			c.embed(Observation{Frame: frame}, embedding)
+		}
+	})
+	b.Run("per pixel", func(b *testing.B) {
+		for range b.N {
+			embedPerPixel(frame, rand.New(rand.NewSource(1)), embedding)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package pong
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Render draws the paddles and the balls into a grayscale frame of the size of the arena,
+// frame is reused if it has the right size
+func (w *World) Render(frame *image.Gray) *image.Gray {
+	bounds := image.Rect(0, 0, w.Width, w.Height)
+	if frame == nil || frame.Rect != bounds {
+		frame = image.NewGray(bounds)
+	}
+	draw.Draw(frame, bounds, image.NewUniform(BgColor), image.Point{}, draw.Src)
+	for _, p := range [...]*Paddle{w.Player1, w.Player2} {
+		rect := image.Rect(int(p.X), int(p.Y)-p.Height/2, int(p.X)+p.Width, int(p.Y)+p.Height/2)
+		draw.Draw(frame, rect, image.NewUniform(p.Color), image.Point{}, draw.Src)
+	}
+	for _, b := range w.Balls {
+		rect := image.Rect(int(b.X-b.Radius), int(b.Y-b.Radius), int(b.X+b.Radius), int(b.Y+b.Radius))
+		draw.Draw(frame, rect, image.NewUniform(b.Color), image.Point{}, draw.Src)
+	}
+	return frame
+}
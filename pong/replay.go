@@ -0,0 +1,212 @@
+package pong
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ReplayVersion is the version of the replay file format
+const ReplayVersion = 4
+
+var replayMagic = [4]byte{'P', 'R', 'P', 'L'}
+
+// maxReplayRules is the longest JSON of the rules of a replay that is read
+const maxReplayRules = 1 << 20
+
+// Replay is a recording of every tick's inputs of a match,
+// each tick is a byte of flags followed by the analog axis of each player that has one as a float32
+type Replay struct {
+	AiMode      bool
+	Balls       int
+	Rules       Rules
+	GameSeed    int64
+	NetworkSeed int64
+	Width       int
+	Height      int
+	Inputs      []Inputs
+}
+
+type replayHeader struct {
+	Magic       [4]byte
+	Version     uint16
+	AiMode      uint8
+	Balls       uint8
+	GameSeed    int64
+	NetworkSeed int64
+	Width       uint32
+	Height      uint32
+	Ticks       uint32
+	Rules       uint32
+}
+
+const (
+	player1Up = 1 << iota
+	player1Down
+	player2Up
+	player2Down
+	player1Axis
+	player2Axis
+)
+
+// Record appends a tick's inputs to the replay
+func (r *Replay) Record(inputs Inputs) {
+	r.Inputs = append(r.Inputs, inputs)
+}
+
+// Write writes the replay
+func (r *Replay) Write(output io.Writer) error {
+	header := replayHeader{
+		Magic:       replayMagic,
+		Version:     ReplayVersion,
+		Balls:       uint8(r.Balls),
+		GameSeed:    r.GameSeed,
+		NetworkSeed: r.NetworkSeed,
+		Width:       uint32(r.Width),
+		Height:      uint32(r.Height),
+		Ticks:       uint32(len(r.Inputs)),
+	}
+	if r.AiMode {
+		header.AiMode = 1
+	}
+	rules, err := json.Marshal(r.Rules)
+	if err != nil {
+		return err
+	}
+	header.Rules = uint32(len(rules))
+	err = binary.Write(output, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	_, err = output.Write(rules)
+	if err != nil {
+		return err
+	}
+	ticks := make([]byte, 0, len(r.Inputs))
+	for _, inputs := range r.Inputs {
+		ticks = encodeInputs(ticks, inputs)
+	}
+	_, err = output.Write(ticks)
+	return err
+}
+
+// ReadReplay reads a replay
+func ReadReplay(input io.Reader) (*Replay, error) {
+	header := replayHeader{}
+	err := binary.Read(input, binary.LittleEndian, &header)
+	if err != nil {
+		return nil, err
+	}
+	if header.Magic != replayMagic {
+		return nil, errors.New("not a replay file")
+	}
+	// older versions were recorded with different physics, they can't be played back the same way
+	if header.Version != ReplayVersion {
+		return nil, fmt.Errorf("unsupported replay version: %d", header.Version)
+	}
+	if header.Rules > maxReplayRules {
+		return nil, fmt.Errorf("the rules of the replay are too long: %d", header.Rules)
+	}
+	rules := make([]byte, header.Rules)
+	_, err = io.ReadFull(input, rules)
+	if err != nil {
+		return nil, err
+	}
+	ticks, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	// every tick is at least one byte
+	if uint64(header.Ticks) > uint64(len(ticks)) {
+		return nil, fmt.Errorf("the replay has %d ticks but only %d bytes of them", header.Ticks, len(ticks))
+	}
+	r := &Replay{
+		AiMode:      header.AiMode != 0,
+		Balls:       max(int(header.Balls), 1),
+		GameSeed:    header.GameSeed,
+		NetworkSeed: header.NetworkSeed,
+		Width:       int(header.Width),
+		Height:      int(header.Height),
+		Inputs:      make([]Inputs, header.Ticks),
+	}
+	err = json.Unmarshal(rules, &r.Rules)
+	if err != nil {
+		return nil, err
+	}
+	err = r.Rules.Validate()
+	if err != nil {
+		return nil, err
+	}
+	for i := range r.Inputs {
+		r.Inputs[i], ticks, err = decodeInputs(ticks)
+		if err != nil {
+			return nil, fmt.Errorf("tick %d: %w", i, err)
+		}
+	}
+	return r, nil
+}
+
+func encodeInputs(ticks []byte, inputs Inputs) []byte {
+	tick := byte(0)
+	if inputs.Player1.Up {
+		tick |= player1Up
+	}
+	if inputs.Player1.Down {
+		tick |= player1Down
+	}
+	if inputs.Player2.Up {
+		tick |= player2Up
+	}
+	if inputs.Player2.Down {
+		tick |= player2Down
+	}
+	if inputs.Player1.Axis != 0 {
+		tick |= player1Axis
+	}
+	if inputs.Player2.Axis != 0 {
+		tick |= player2Axis
+	}
+	ticks = append(ticks, tick)
+	if inputs.Player1.Axis != 0 {
+		ticks = binary.LittleEndian.AppendUint32(ticks, math.Float32bits(inputs.Player1.Axis))
+	}
+	if inputs.Player2.Axis != 0 {
+		ticks = binary.LittleEndian.AppendUint32(ticks, math.Float32bits(inputs.Player2.Axis))
+	}
+	return ticks
+}
+
+func decodeInputs(ticks []byte) (Inputs, []byte, error) {
+	if len(ticks) == 0 {
+		return Inputs{}, nil, io.ErrUnexpectedEOF
+	}
+	tick := ticks[0]
+	ticks = ticks[1:]
+	inputs := Inputs{
+		Player1: Input{
+			Up:   tick&player1Up != 0,
+			Down: tick&player1Down != 0,
+		},
+		Player2: Input{
+			Up:   tick&player2Up != 0,
+			Down: tick&player2Down != 0,
+		},
+	}
+	for _, axis := range [...]struct {
+		flag  byte
+		input *Input
+	}{{player1Axis, &inputs.Player1}, {player2Axis, &inputs.Player2}} {
+		if tick&axis.flag == 0 {
+			continue
+		}
+		if len(ticks) < 4 {
+			return Inputs{}, nil, io.ErrUnexpectedEOF
+		}
+		axis.input.Axis = math.Float32frombits(binary.LittleEndian.Uint32(ticks))
+		ticks = ticks[4:]
+	}
+	return inputs, ticks, nil
+}
@@ -0,0 +1,108 @@
+package pong
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// worldState is what a replay has to reproduce after every tick
+type worldState struct {
+	Balls            []Ball
+	Player1, Player2 Paddle
+	Ticks            int
+}
+
+func snapshot(w *World) worldState {
+	s := worldState{Player1: *w.Player1, Player2: *w.Player2, Ticks: w.Ticks}
+	for _, ball := range w.Balls {
+		s.Balls = append(s.Balls, *ball)
+	}
+	return s
+}
+
+// analogTracker tracks the incoming ball with the analog axis at half thrust
+type analogTracker struct{}
+
+func (analogTracker) Act(o Observation) Input {
+	return Input{Axis: Tracker{}.Act(o).Thrust() / 2}
+}
+
+// step plays inputs like the game does: the world is reset after a point unless the balls are served on their own
+func step(w *World, inputs Inputs) {
+	events := w.Step(inputs)
+	if events.Player1.Points+events.Player2.Points > 0 && len(w.Balls) == 1 {
+		w.Reset()
+	}
+}
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name             string
+		balls            int
+		player1, player2 Controller
+	}{
+		{"tracker vs medium", 1, Tracker{}, NewAI(Medium, 2)},
+		{"analog vs hard", 1, analogTracker{}, NewAI(Hard, 3)},
+		{"crazy", CrazyBalls, NewAI(Easy, 4), analogTracker{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules := DefaultRules()
+			rules.MaxScore = 3
+			rules.PaddleAcceleration, rules.PaddleDeceleration = 1, 2
+			w, err := NewWorld(800, 600, rules, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.SetBalls(test.balls)
+			w.Restart()
+			record := &Replay{
+				Balls:    len(w.Balls),
+				Rules:    w.Rules,
+				GameSeed: w.Seed,
+				Width:    w.Width,
+				Height:   w.Height,
+			}
+			var states []worldState
+			for w.Winner() == 0 && w.Ticks < 5*60*TPS {
+				inputs := Inputs{
+					Player1: test.player1.Act(w.Observe(1)),
+					Player2: test.player2.Act(w.Observe(2)),
+				}
+				record.Record(inputs)
+				step(w, inputs)
+				states = append(states, snapshot(w))
+			}
+			if w.Player1.Score+w.Player2.Score == 0 {
+				t.Fatal("no point was scored, the replay wouldn't show much")
+			}
+
+			output := bytes.Buffer{}
+			err = record.Write(&output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			replay, err := ReadReplay(&output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(replay.Inputs) != len(record.Inputs) {
+				t.Fatalf("%d ticks were read, %d were recorded", len(replay.Inputs), len(record.Inputs))
+			}
+
+			played, err := NewWorld(replay.Width, replay.Height, replay.Rules, replay.GameSeed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			played.SetBalls(replay.Balls)
+			played.Restart()
+			for tick, inputs := range replay.Inputs {
+				step(played, inputs)
+				if state := snapshot(played); !reflect.DeepEqual(state, states[tick]) {
+					t.Fatalf("tick %d: the replay is at\n%+v\nthe match was at\n%+v", tick, state, states[tick])
+				}
+			}
+		})
+	}
+}
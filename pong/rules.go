@@ -0,0 +1,114 @@
+package pong
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// TPS is the number of ticks per second
+const TPS = 60
+
+// ServePolicy decides which player serves after a point
+type ServePolicy string
+
+const (
+	// ServeWinner makes the player that won the point serve
+	ServeWinner ServePolicy = "winner"
+	// ServeAlternate switches the server every two points
+	ServeAlternate ServePolicy = "alternate"
+)
+
+// Rules are the rules of a match
+type Rules struct {
+	// MaxScore is the score a player needs to win
+	MaxScore int `json:"max_score"`
+	// WinByTwo makes a player need a two point lead to win
+	WinByTwo bool `json:"win_by_two"`
+	// Serve is the serve policy
+	Serve ServePolicy `json:"serve"`
+	// TimeLimit is the length of a match in seconds, 0 for no limit
+	TimeLimit int `json:"time_limit"`
+	// Countdown is the number of seconds before a served ball moves
+	Countdown int `json:"countdown"`
+	// SpeedUpdateCount is the number of hits between speed ups
+	SpeedUpdateCount int `json:"speed_update_count"`
+	// SpeedIncrement is how much faster the paddles get each speed up
+	SpeedIncrement float32 `json:"speed_increment"`
+	// BallVelocity is the initial velocity of the ball on each axis of a 45 degree serve
+	BallVelocity float32 `json:"ball_velocity"`
+	// PaddleSpeed is the initial speed of the paddles
+	PaddleSpeed float32 `json:"paddle_speed"`
+	// PaddleAcceleration is how much faster the paddles can get each tick, 0 for at once
+	PaddleAcceleration float32 `json:"paddle_acceleration"`
+	// PaddleDeceleration is how much slower the paddles can get each tick, 0 for at once
+	PaddleDeceleration float32 `json:"paddle_deceleration"`
+}
+
+// DefaultRules returns the rules of a VS game
+func DefaultRules() Rules {
+	return Rules{
+		MaxScore:         11,
+		Serve:            ServeWinner,
+		SpeedUpdateCount: SpeedUpdateCount,
+		SpeedIncrement:   SpeedIncrement,
+		BallVelocity:     InitBallVelocity,
+		PaddleSpeed:      InitPaddleSpeed,
+	}
+}
+
+// AiRules returns the rules of an AI game
+func AiRules() Rules {
+	rules := DefaultRules()
+	rules.MaxScore = 100
+	return rules
+}
+
+// LoadRules reads rules in JSON, missing values are taken from the default rules
+func LoadRules(input io.Reader) (Rules, error) {
+	rules := DefaultRules()
+	decoder := json.NewDecoder(input)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&rules)
+	if err != nil {
+		return rules, err
+	}
+	return rules, rules.Validate()
+}
+
+// Validate checks that the rules make sense
+func (r Rules) Validate() error {
+	var errs []error
+	if r.MaxScore < 1 {
+		errs = append(errs, fmt.Errorf("max_score should be at least 1: %d", r.MaxScore))
+	}
+	if r.Serve != ServeWinner && r.Serve != ServeAlternate {
+		errs = append(errs, fmt.Errorf("serve should be %q or %q: %q", ServeWinner, ServeAlternate, r.Serve))
+	}
+	if r.TimeLimit < 0 {
+		errs = append(errs, fmt.Errorf("time_limit should not be negative: %d", r.TimeLimit))
+	}
+	if r.Countdown < 0 {
+		errs = append(errs, fmt.Errorf("countdown should not be negative: %d", r.Countdown))
+	}
+	if r.SpeedUpdateCount < 1 {
+		errs = append(errs, fmt.Errorf("speed_update_count should be at least 1: %d", r.SpeedUpdateCount))
+	}
+	if r.SpeedIncrement < 0 {
+		errs = append(errs, fmt.Errorf("speed_increment should not be negative: %g", r.SpeedIncrement))
+	}
+	if r.BallVelocity <= 0 {
+		errs = append(errs, fmt.Errorf("ball_velocity should be positive: %g", r.BallVelocity))
+	}
+	if r.PaddleSpeed <= 0 {
+		errs = append(errs, fmt.Errorf("paddle_speed should be positive: %g", r.PaddleSpeed))
+	}
+	if r.PaddleAcceleration < 0 {
+		errs = append(errs, fmt.Errorf("paddle_acceleration should not be negative: %g", r.PaddleAcceleration))
+	}
+	if r.PaddleDeceleration < 0 {
+		errs = append(errs, fmt.Errorf("paddle_deceleration should not be negative: %g", r.PaddleDeceleration))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,156 @@
+package pong
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// maxSafetensorsHeader is the largest JSON header of a safetensors file that is read
+const maxSafetensorsHeader = 100 << 20
+
+// safetensor is the description of a tensor in the header of a safetensors file
+type safetensor struct {
+	DType       string `json:"dtype"`
+	Shape       []int  `json:"shape"`
+	DataOffsets [2]int `json:"data_offsets"`
+}
+
+// safetensorsDType returns the safetensors dtype of the values of a matrix
+func safetensorsDType[T Float]() string {
+	if dtype[T]() == Float32 {
+		return "F32"
+	}
+	return "F64"
+}
+
+// WriteSafetensors writes the named matrices of the set as a safetensors file of tensors of shape [rows, cols],
+// the data is in the order of the sizes
+func (s Set[T]) WriteSafetensors(output io.Writer) error {
+	header := make(map[string]safetensor, len(s.Sizes))
+	data := bytes.Buffer{}
+	matrices, err := s.ordered()
+	if err != nil {
+		return err
+	}
+	for i, matrix := range matrices {
+		size := s.Sizes[i]
+		if len(matrix.Data) != matrix.Cols*matrix.Rows {
+			return fmt.Errorf("%d values don't fit a %dx%d matrix", len(matrix.Data), matrix.Cols, matrix.Rows)
+		}
+		if size.Name == "__metadata__" {
+			return fmt.Errorf("a matrix can't be named %q", size.Name)
+		}
+		begin := data.Len()
+		err := writeData(&data, matrix.Data)
+		if err != nil {
+			return err
+		}
+		header[size.Name] = safetensor{
+			DType:       safetensorsDType[T](),
+			Shape:       []int{matrix.Rows, matrix.Cols},
+			DataOffsets: [2]int{begin, data.Len()},
+		}
+	}
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	// the header is padded with spaces so that the data is aligned to 8 bytes
+	for len(encoded)%8 != 0 {
+		encoded = append(encoded, ' ')
+	}
+	err = binary.Write(output, binary.LittleEndian, uint64(len(encoded)))
+	if err != nil {
+		return err
+	}
+	_, err = output.Write(encoded)
+	if err != nil {
+		return err
+	}
+	_, err = data.WriteTo(output)
+	return err
+}
+
+// ReadSafetensors reads the F32 and F64 tensors of a safetensors file into a set in the order of their data,
+// the values are converted to the type of the set and all the dimensions but the last one are rows
+func ReadSafetensors[T Float](input io.Reader) (Set[T], error) {
+	var length uint64
+	err := binary.Read(input, binary.LittleEndian, &length)
+	if err != nil {
+		return Set[T]{}, err
+	}
+	if length > maxSafetensorsHeader {
+		return Set[T]{}, fmt.Errorf("the safetensors header is too long: %d", length)
+	}
+	encoded := make([]byte, length)
+	_, err = io.ReadFull(input, encoded)
+	if err != nil {
+		return Set[T]{}, err
+	}
+	header := make(map[string]json.RawMessage)
+	err = json.Unmarshal(encoded, &header)
+	if err != nil {
+		return Set[T]{}, err
+	}
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return Set[T]{}, err
+	}
+
+	type entry struct {
+		name string
+		safetensor
+	}
+	entries := make([]entry, 0, len(header))
+	for name, raw := range header {
+		if name == "__metadata__" {
+			continue
+		}
+		e := entry{name: name}
+		err = json.Unmarshal(raw, &e.safetensor)
+		if err != nil {
+			return Set[T]{}, fmt.Errorf("tensor %q: %w", name, err)
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DataOffsets[0] < entries[j].DataOffsets[0]
+	})
+
+	matrices := make([]Matrix[T], 0, len(entries))
+	for _, e := range entries {
+		size, err := shapeSize(e.Shape)
+		if err != nil {
+			return Set[T]{}, fmt.Errorf("tensor %q: %w", e.name, err)
+		}
+		width := 0
+		switch e.DType {
+		case "F32":
+			width = 4
+		case "F64":
+			width = 8
+		default:
+			return Set[T]{}, fmt.Errorf("tensor %q has an unsupported dtype, it should be F32 or F64: %s", e.name, e.DType)
+		}
+		begin, end := e.DataOffsets[0], e.DataOffsets[1]
+		if begin < 0 || end > len(data) || end-begin != width*size.Cols*size.Rows {
+			return Set[T]{}, fmt.Errorf("tensor %q has invalid data offsets: %v", e.name, e.DataOffsets)
+		}
+		m := Matrix[T]{Size: size}
+		m.Name = e.name
+		if width == 4 {
+			m.Data, err = readData[float32, T](bytes.NewReader(data[begin:end]), size.Cols*size.Rows)
+		} else {
+			m.Data, err = readData[float64, T](bytes.NewReader(data[begin:end]), size.Cols*size.Rows)
+		}
+		if err != nil {
+			return Set[T]{}, err
+		}
+		matrices = append(matrices, m)
+	}
+	return newSet(matrices)
+}
@@ -0,0 +1,32 @@
+package pong
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestSafetensors(t *testing.T) {
+	t.Run("f32", func(t *testing.T) { testSafetensors[float32](t, "f32") })
+	t.Run("f64", func(t *testing.T) { testSafetensors[float64](t, "f64") })
+}
+
+func testSafetensors[T Float](t *testing.T, suffix string) {
+	set := goldenSet[T](t)
+	output := bytes.Buffer{}
+	err := set.WriteSafetensors(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := binary.LittleEndian.Uint64(output.Bytes())
+	if header%8 != 0 {
+		t.Errorf("the header is %d bytes, the data isn't aligned to 8 bytes", header)
+	}
+	golden(t, "set_"+suffix+".safetensors", output.Bytes())
+
+	read, err := ReadSafetensors[T](&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equalSets(t, read, set)
+}
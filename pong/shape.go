@@ -0,0 +1,118 @@
+package pong
+
+import "fmt"
+
+// ShapeError is an operation on matrices whose shapes don't fit
+type ShapeError struct {
+	Op     string
+	A, B   Size
+	Reason string
+}
+
+func (e *ShapeError) Error() string {
+	return fmt.Sprintf("%s: %s and %s: %s", e.Op, shape(e.A), shape(e.B), e.Reason)
+}
+
+// shape formats a size as cols x rows, with the name of the matrix if it has one
+func shape(s Size) string {
+	if s.Name != "" {
+		return fmt.Sprintf("%q %dx%d", s.Name, s.Cols, s.Rows)
+	}
+	return fmt.Sprintf("%dx%d", s.Cols, s.Rows)
+}
+
+// checkData checks that the values of a matrix fit its shape
+func checkData[T Float](op string, m Matrix[T]) error {
+	if len(m.Data) != m.Cols*m.Rows {
+		return fmt.Errorf("%s: %d values don't fit the %s matrix", op, len(m.Data), shape(m.Size))
+	}
+	return nil
+}
+
+// broadcast checks that n can be combined value by value with m, n should either:
+//   - have the shape of m
+//   - be a single row with the columns of m, it is then repeated for every row of m like a bias
+//   - be a single value, it is then repeated for every value of m
+func broadcast[T Float](op string, m, n Matrix[T]) error {
+	for _, matrix := range []Matrix[T]{m, n} {
+		if err := checkData(op, matrix); err != nil {
+			return err
+		}
+	}
+	switch {
+	case n.Cols == m.Cols && n.Rows == m.Rows:
+	case n.Cols == m.Cols && n.Rows == 1:
+	case n.Cols == 1 && n.Rows == 1:
+	default:
+		return &ShapeError{
+			Op:     op,
+			A:      m.Size,
+			B:      n.Size,
+			Reason: "the second matrix should have the same shape, be a single row of the same columns or be a single value",
+		}
+	}
+	return nil
+}
+
+// CheckedMulT is MulT returning an error when the columns of the matrices are not the same
+func (m Matrix[T]) CheckedMulT(n Matrix[T]) (Matrix[T], error) {
+	for _, matrix := range []Matrix[T]{m, n} {
+		if err := checkData("mul", matrix); err != nil {
+			return Matrix[T]{}, err
+		}
+	}
+	if m.Cols != n.Cols {
+		return Matrix[T]{}, &ShapeError{Op: "mul", A: m.Size, B: n.Size, Reason: "the columns should be the same"}
+	}
+	return m.MulT(n), nil
+}
+
+// CheckedAdd is Add returning an error when n can't be broadcast to m
+func (m Matrix[T]) CheckedAdd(n Matrix[T]) (Matrix[T], error) {
+	if err := broadcast("add", m, n); err != nil {
+		return Matrix[T]{}, err
+	}
+	return m.Add(n), nil
+}
+
+// CheckedSub is Sub returning an error when n can't be broadcast to m
+func (m Matrix[T]) CheckedSub(n Matrix[T]) (Matrix[T], error) {
+	if err := broadcast("sub", m, n); err != nil {
+		return Matrix[T]{}, err
+	}
+	return m.Sub(n), nil
+}
+
+// CheckedHadamard is Hadamard returning an error when n can't be broadcast to m
+func (m Matrix[T]) CheckedHadamard(n Matrix[T]) (Matrix[T], error) {
+	if err := broadcast("hadamard", m, n); err != nil {
+		return Matrix[T]{}, err
+	}
+	return m.Hadamard(n), nil
+}
+
+// CheckedSelfAttention is SelfAttention returning an error when Q and K don't have the same columns
+// or when V doesn't have a row for every row of Q
+func CheckedSelfAttention[T Float](Q, K, V Matrix[T]) (Matrix[T], error) {
+	for _, matrix := range []Matrix[T]{Q, K, V} {
+		if err := checkData("attention", matrix); err != nil {
+			return Matrix[T]{}, err
+		}
+	}
+	if Q.Cols != K.Cols {
+		return Matrix[T]{}, &ShapeError{Op: "attention", A: Q.Size, B: K.Size, Reason: "the columns of Q and K should be the same"}
+	}
+	if V.Rows != Q.Rows {
+		return Matrix[T]{}, &ShapeError{Op: "attention", A: Q.Size, B: V.Size, Reason: "the rows of Q and V should be the same"}
+	}
+	return SelfAttention(Q, K, V), nil
+}
+
+// Lookup returns the matrix named name or an error if the set doesn't have it
+func (s Set[T]) Lookup(name string) (Matrix[T], error) {
+	m, ok := s.ByName[name]
+	if !ok {
+		return Matrix[T]{}, fmt.Errorf("no matrix named %q", name)
+	}
+	return *m, nil
+}
@@ -0,0 +1,174 @@
+package pong
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// named creates a named matrix of cols x rows counting values
+func named(name string, cols, rows int) Matrix[float64] {
+	m := NewMatrix(cols, rows, make([]float64, cols*rows)...)
+	m.Name = name
+	for i := range m.Data {
+		m.Data[i] = float64(i + 1)
+	}
+	return m
+}
+
+// checkShapeError checks that err is a shape error naming the shapes of both matrices
+func checkShapeError(t *testing.T, err error, a, b Matrix[float64]) {
+	t.Helper()
+	var shapeError *ShapeError
+	if !errors.As(err, &shapeError) {
+		t.Fatalf("the error should be a shape error: %v", err)
+	}
+	for _, m := range []Matrix[float64]{a, b} {
+		if !strings.Contains(err.Error(), shape(m.Size)) {
+			t.Errorf("the error doesn't name the shape %s: %v", shape(m.Size), err)
+		}
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	ops := []struct {
+		name string
+		op   func(m, n Matrix[float64]) (Matrix[float64], error)
+		f    func(x, y float64) float64
+	}{
+		{"add", Matrix[float64].CheckedAdd, func(x, y float64) float64 { return x + y }},
+		{"sub", Matrix[float64].CheckedSub, func(x, y float64) float64 { return x - y }},
+		{"hadamard", Matrix[float64].CheckedHadamard, func(x, y float64) float64 { return x * y }},
+	}
+	tests := []struct {
+		name string
+		m, n Matrix[float64]
+		ok   bool
+		// at returns the index of the value of n combined with the value i of m
+		at func(i int) int
+	}{
+		{"same shape", named("m", 3, 2), named("n", 3, 2), true, func(i int) int { return i }},
+		{"single row bias", named("m", 3, 2), named("n", 3, 1), true, func(i int) int { return i % 3 }},
+		{"scalar", named("m", 3, 2), named("n", 1, 1), true, func(int) int { return 0 }},
+		{"other columns", named("m", 3, 2), named("n", 2, 2), false, nil},
+		{"other rows", named("m", 3, 2), named("n", 3, 4), false, nil},
+		{"single column", named("m", 3, 2), named("n", 1, 2), false, nil},
+		{"larger", named("m", 3, 1), named("n", 3, 2), false, nil},
+	}
+	for _, op := range ops {
+		for _, test := range tests {
+			t.Run(op.name+" "+test.name, func(t *testing.T) {
+				o, err := op.op(test.m, test.n)
+				if !test.ok {
+					checkShapeError(t, err, test.m, test.n)
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if o.Cols != test.m.Cols || o.Rows != test.m.Rows {
+					t.Fatalf("the result is %s, it should have the shape of %s", shape(o.Size), shape(test.m.Size))
+				}
+				for i, value := range test.m.Data {
+					if expected := op.f(value, test.n.Data[test.at(i)]); o.Data[i] != expected {
+						t.Fatalf("value %d is %v, it should be %v", i, o.Data[i], expected)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestCheckedData(t *testing.T) {
+	m, n := named("m", 3, 2), named("n", 3, 2)
+	n.Data = n.Data[:5]
+	for name, op := range map[string]func(m, n Matrix[float64]) (Matrix[float64], error){
+		"add":      Matrix[float64].CheckedAdd,
+		"sub":      Matrix[float64].CheckedSub,
+		"hadamard": Matrix[float64].CheckedHadamard,
+		"mul":      Matrix[float64].CheckedMulT,
+	} {
+		_, err := op(m, n)
+		if err == nil {
+			t.Errorf("%s: 5 values were taken for a 3x2 matrix", name)
+		}
+	}
+}
+
+func TestCheckedMulT(t *testing.T) {
+	o, err := named("m", 3, 2).CheckedMulT(named("n", 3, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Cols != 2 || o.Rows != 4 {
+		t.Fatalf("the result is %s, it should be 2x4", shape(o.Size))
+	}
+	m, n := named("m", 3, 2), named("n", 2, 3)
+	_, err = m.CheckedMulT(n)
+	checkShapeError(t, err, m, n)
+}
+
+func TestCheckedSelfAttention(t *testing.T) {
+	_, err := CheckedSelfAttention(named("q", 3, 2), named("k", 3, 4), named("v", 5, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, k, v := named("q", 3, 2), named("k", 4, 4), named("v", 5, 2)
+	_, err = CheckedSelfAttention(q, k, v)
+	checkShapeError(t, err, q, k)
+	q, k, v = named("q", 3, 2), named("k", 3, 4), named("v", 5, 3)
+	_, err = CheckedSelfAttention(q, k, v)
+	checkShapeError(t, err, q, v)
+}
+
+// transformerSet returns the matrices of a transformer of width d for inputs and outputs of cols values and tags
+func transformerSet(t *testing.T, d, tags, cols int) Set[float64] {
+	var matrices []Matrix[float64]
+	add := func(name string, cols, rows int) {
+		m := named(name, cols, rows)
+		for i := range m.Data {
+			m.Data[i] = 1 / m.Data[i]
+		}
+		matrices = append(matrices, m)
+	}
+	add("itags", tags, 2)
+	add("otags", tags, 2)
+	add("lembeddingIn", tags+cols, d)
+	add("lembeddingOut", tags+cols, d)
+	for _, name := range []string{"bembeddingIn", "bembeddingOut", "b1In", "b1Out"} {
+		add(name, d, 1)
+	}
+	for _, name := range []string{"inQ", "inK", "inV", "l1In", "outQ1", "outK1", "outV1", "outQ2", "outK2", "outV2", "l1Out"} {
+		add(name, d, d)
+	}
+	add("linear", d, 3)
+	set, err := newSet(matrices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+func TestTransformer(t *testing.T) {
+	inputs, outputs := named("inputs", 4, 2), named("outputs", 4, 2)
+	o, err := Transformer(transformerSet(t, 6, 2, 4), inputs, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Cols != 3 || o.Rows != 2 {
+		t.Fatalf("the result is %s, it should be 3x2", shape(o.Size))
+	}
+
+	missing := transformerSet(t, 6, 2, 4)
+	delete(missing.ByName, "l1Out")
+	_, err = Transformer(missing, inputs, outputs)
+	if err == nil || !strings.Contains(err.Error(), `"l1Out"`) {
+		t.Fatalf("the error should name the missing matrix: %v", err)
+	}
+
+	tagged := transformerSet(t, 6, 2, 4)
+	itags := named("itags", 2, 3)
+	tagged.ByName["itags"] = &itags
+	_, err = Transformer(tagged, inputs, outputs)
+	checkShapeError(t, err, itags, inputs)
+}
@@ -0,0 +1,59 @@
+package pong
+
+// FrameStack stacks the last frames a network controller perceived so that it can tell where things are going
+type FrameStack struct {
+	// Frames is the number of frames that are stacked, 0 or 1 for the last frame only
+	Frames int
+	// Difference stacks the differences between consecutive frames after the last frame instead of the older frames
+	Difference bool
+	// history are the last frames, history[0] is the newest
+	history [][]float64
+	stacked []float64
+}
+
+// Push adds the newest frame and returns the stack, the oldest frame is repeated until there are enough frames
+func (s *FrameStack) Push(frame []float64) []float64 {
+	if s.Frames <= 1 {
+		return frame
+	}
+	if len(s.history) > 0 && len(s.history[0]) != len(frame) {
+		s.Reset()
+	}
+	if len(s.history) < s.Frames {
+		s.history = append(s.history, make([]float64, len(frame)))
+	}
+	// the oldest buffer is reused for the newest frame
+	oldest := s.history[len(s.history)-1]
+	copy(s.history[1:], s.history[:len(s.history)-1])
+	s.history[0] = oldest
+	copy(oldest, frame)
+
+	size := len(frame)
+	if cap(s.stacked) < s.Frames*size {
+		s.stacked = make([]float64, s.Frames*size)
+	}
+	s.stacked = s.stacked[:s.Frames*size]
+	for i := range s.Frames {
+		stacked := s.stacked[i*size : (i+1)*size]
+		if !s.Difference || i == 0 {
+			copy(stacked, s.at(i))
+			continue
+		}
+		// how the frame changed between the i-th and the (i-1)-th newest frames
+		newer, older := s.at(i-1), s.at(i)
+		for ii := range stacked {
+			stacked[ii] = newer[ii] - older[ii]
+		}
+	}
+	return s.stacked
+}
+
+// at returns the i-th newest frame, or the oldest frame if there aren't as many frames
+func (s *FrameStack) at(i int) []float64 {
+	return s.history[min(i, len(s.history)-1)]
+}
+
+// Reset forgets the frames
+func (s *FrameStack) Reset() {
+	s.history = s.history[:0]
+}
@@ -0,0 +1,124 @@
+package pong
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Kind is the kind of a telemetry entry
+type Kind string
+
+const (
+	// DecisionKind is the decision of a network controller, the value is a Decision
+	DecisionKind Kind = "decision"
+	// RewireKind is a connection moved by Network.Iterate, the value is a Rewire
+	RewireKind Kind = "rewire"
+	// WalkKind are the walk counts after Network.Iterate, the value is a Walk
+	WalkKind Kind = "walk"
+	// GameKind are the hits and points of a tick, the value is a GameEvents
+	GameKind Kind = "game"
+)
+
+// Decision are the values a network controller decided on
+type Decision struct {
+	Player int     `json:"player"`
+	Tick   int     `json:"tick"`
+	Sub    float64 `json:"sub"`
+	Sum    float64 `json:"sum"`
+	Action Action  `json:"action"`
+	Axis   float32 `json:"axis,omitempty"`
+}
+
+// Rewire is a connection of a neuron that was moved to another neuron
+type Rewire struct {
+	Neuron int `json:"neuron"`
+	Slot   int `json:"slot"`
+	From   int `json:"from"`
+	To     int `json:"to"`
+}
+
+// Walk are the walk counts of the connections of each neuron
+type Walk struct {
+	Counts [][]float64 `json:"counts"`
+}
+
+// GameEvents are the things that happened during a tick with the score after it
+type GameEvents struct {
+	Tick   int    `json:"tick"`
+	Events Events `json:"events"`
+	Rally  int    `json:"rally"`
+	Score  [2]int `json:"score"`
+}
+
+// Entry is a telemetry entry
+type Entry struct {
+	Kind  Kind `json:"kind"`
+	Value any  `json:"value"`
+}
+
+// Telemetry records what the networks and the world are doing
+type Telemetry interface {
+	Record(entry Entry)
+}
+
+// NoTelemetry is a telemetry that records nothing
+type NoTelemetry struct{}
+
+// Record drops the entry
+func (NoTelemetry) Record(entry Entry) {}
+
+// JSONL is a telemetry that writes one JSON entry per line
+type JSONL struct {
+	encoder *json.Encoder
+	// Err is the first error that happened while writing
+	Err error
+}
+
+// NewJSONL creates a JSONL telemetry that writes to output
+func NewJSONL(output io.Writer) *JSONL {
+	return &JSONL{
+		encoder: json.NewEncoder(output),
+	}
+}
+
+// Record writes the entry, nothing is written anymore after an error
+func (j *JSONL) Record(entry Entry) {
+	if j.Err != nil {
+		return
+	}
+	j.Err = j.encoder.Encode(entry)
+}
+
+// Ring is a telemetry that keeps the last entries in memory
+type Ring struct {
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewRing creates a ring that keeps the last size entries
+func NewRing(size int) *Ring {
+	return &Ring{
+		entries: make([]Entry, size),
+	}
+}
+
+// Record adds the entry, dropping the oldest entry if the ring is full
+func (r *Ring) Record(entry Entry) {
+	if len(r.entries) == 0 {
+		return
+	}
+	r.entries[r.next] = entry
+	r.next++
+	if r.next == len(r.entries) {
+		r.next, r.full = 0, true
+	}
+}
+
+// Entries returns the entries from the oldest to the newest
+func (r *Ring) Entries() []Entry {
+	if !r.full {
+		return append([]Entry(nil), r.entries[:r.next]...)
+	}
+	return append(append([]Entry(nil), r.entries[r.next:]...), r.entries[:r.next]...)
+}
@@ -1,7 +1,6 @@
 package pong
 
 import (
-	"github.com/hajimehoshi/ebiten"
 	"image/color"
 )
 
@@ -10,15 +9,6 @@ type Position struct {
 	X, Y float32
 }
 
-// GetCenter returns the center position on screen
-func GetCenter(screen *ebiten.Image) Position {
-	w, h := screen.Size()
-	return Position{
-		X: float32(w / 2),
-		Y: float32(h / 2),
-	}
-}
-
 // GameState is an enum that represents all possible game states
 type GameState byte
 
@@ -0,0 +1,350 @@
+package pong
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+)
+
+const (
+	SpeedUpdateCount = 6
+	SpeedIncrement   = 0.5
+	// CrazyBalls is the number of balls in crazy mode
+	CrazyBalls = 3
+	// BallInterval is the number of ticks between the starts of two balls
+	BallInterval = 60
+	// MaxServeAngle is the steepest angle from the horizontal of a serve
+	MaxServeAngle = math.Pi / 4
+)
+
+// Inputs are the inputs of both players for one tick
+type Inputs struct {
+	Player1 Input
+	Player2 Input
+}
+
+// PlayerEvents are the things that happened to a player during one tick
+type PlayerEvents struct {
+	// Hits is the number of times a ball bounced off the player's paddle
+	Hits int `json:"hits"`
+	// Points is the number of points won by the player
+	Points int `json:"points"`
+}
+
+// Events are the things that happened during one tick
+type Events struct {
+	Player1 PlayerEvents `json:"player1"`
+	Player2 PlayerEvents `json:"player2"`
+}
+
+// World is the headless pong simulation
+type World struct {
+	Width   int
+	Height  int
+	Balls   []*Ball
+	Player1 *Paddle
+	Player2 *Paddle
+	Rally   int
+	Level   int
+	Rules   Rules
+	// Ticks is the number of ticks played in the match
+	Ticks int
+	// Server is the player that serves next
+	Server int
+	// Seed is the seed of Rng at the start of a match
+	Seed int64
+	Rng  *rand.Rand
+	// Telemetry records the hits and the points
+	Telemetry Telemetry
+	// frame is the frame rendered for the controllers by Tick
+	frame *image.Gray
+}
+
+// NewWorld creates a new world with an arena of the given size and one ball,
+// the serves are drawn from a random number generator seeded with seed, it returns an error if the rules are invalid
+func NewWorld(width, height int, rules Rules, seed int64) (*World, error) {
+	err := rules.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+	w := &World{
+		Width:     width,
+		Height:    height,
+		Rules:     rules,
+		Server:    1,
+		Seed:      seed,
+		Rng:       rand.New(rand.NewSource(seed)),
+		Telemetry: NoTelemetry{},
+		Player1: &Paddle{
+			Width:  InitPaddleWidth,
+			Height: InitPaddleHeight,
+			Color:  ObjColor,
+		},
+		Player2: &Paddle{
+			Width:  InitPaddleWidth,
+			Height: InitPaddleHeight,
+			Color:  ObjColor,
+		},
+	}
+	w.SetBalls(1)
+	return w, nil
+}
+
+// SetBalls sets the number of balls on the field and resets the world
+func (w *World) SetBalls(n int) {
+	w.Balls = make([]*Ball, n)
+	for i := range w.Balls {
+		w.Balls[i] = &Ball{
+			Radius: InitBallRadius,
+			Color:  ObjColor,
+		}
+	}
+	w.Reset()
+}
+
+// Center returns the center of the arena
+func (w *World) Center() Position {
+	return Position{
+		X: float32(w.Width / 2),
+		Y: float32(w.Height / 2),
+	}
+}
+
+// Restart starts a new match
+func (w *World) Restart() {
+	w.Player1.Score = 0
+	w.Player2.Score = 0
+	w.Ticks = 0
+	w.Server = 1
+	w.Rng.Seed(w.Seed)
+	w.Reset()
+}
+
+// Winner returns the player that won the match, 0 if the match isn't over
+func (w *World) Winner() int {
+	score1, score2 := w.Player1.Score, w.Player2.Score
+	leader := 0
+	if score1 > score2 {
+		leader = 1
+	} else if score2 > score1 {
+		leader = 2
+	}
+	lead := max(score1-score2, score2-score1)
+	if max(score1, score2) >= w.Rules.MaxScore && (!w.Rules.WinByTwo || lead >= 2) {
+		return leader
+	}
+	// sudden death when the time is up with a tie
+	if w.Rules.TimeLimit > 0 && w.Ticks >= w.Rules.TimeLimit*TPS {
+		return leader
+	}
+	return 0
+}
+
+// score gives a point to a player and picks the next server
+func (w *World) score(player int) {
+	if player == 1 {
+		w.Player1.Score++
+	} else {
+		w.Player2.Score++
+	}
+	switch w.Rules.Serve {
+	case ServeWinner:
+		w.Server = player
+	case ServeAlternate:
+		w.Server = 1 + (w.Player1.Score+w.Player2.Score)/2%2
+	}
+}
+
+// Reset puts the balls and the paddles back in their initial positions, scores are kept
+func (w *World) Reset() {
+	w.resetRally()
+	center := w.Center()
+	w.Player1.Position = Position{
+		X: InitPaddleShift, Y: center.Y}
+	w.Player2.Position = Position{
+		X: float32(w.Width - InitPaddleShift - InitPaddleWidth), Y: center.Y}
+	w.Player1.Velocity = 0
+	w.Player2.Velocity = 0
+	for _, p := range [...]*Paddle{w.Player1, w.Player2} {
+		p.Acceleration = w.Rules.PaddleAcceleration
+		p.Deceleration = w.Rules.PaddleDeceleration
+	}
+	for i, ball := range w.Balls {
+		w.serve(ball, i*BallInterval)
+	}
+}
+
+// resetRally puts the rally, the level and the paddle speeds back to the start of a point
+func (w *World) resetRally() {
+	w.Rally = 0
+	w.Level = 0
+	w.Player1.Speed = w.Rules.PaddleSpeed
+	w.Player2.Speed = w.Rules.PaddleSpeed
+}
+
+// serve puts a ball back in the center heading away from the server at a random angle,
+// the ball starts moving after the countdown and wait ticks
+func (w *World) serve(b *Ball, wait int) {
+	direction := float32(1)
+	if w.Server == 2 {
+		direction = -1
+	}
+	b.Position = w.Center()
+	b.XVelocity = w.Rules.BallVelocity * math.Sqrt2
+	b.YVelocity = 0
+	b.steer((2*w.Rng.Float64()-1)*MaxServeAngle, direction)
+	b.Spin = 0
+	b.Wait = w.Rules.Countdown*TPS + wait
+}
+
+// Step advances the world by one tick
+func (w *World) Step(inputs Inputs) Events {
+	events := Events{}
+	w.Ticks++
+	w.Player1.Move(inputs.Player1, w.Height)
+	w.Player2.Move(inputs.Player2, w.Height)
+
+	for _, ball := range w.Balls {
+		if ball.Wait > 0 {
+			ball.Wait--
+			continue
+		}
+
+		xV := ball.XVelocity
+		ball.Update(w.Player1, w.Player2, w.Height)
+		// rally count
+		if xV*ball.XVelocity < 0 {
+			if ball.X < float32(w.Width/2) {
+				events.Player1.Hits++
+			} else {
+				events.Player2.Hits++
+			}
+
+			w.Rally++
+
+			// spice things up, the balls waiting to be served keep their serve speed
+			if w.Rally%w.Rules.SpeedUpdateCount == 0 {
+				w.Level++
+				for _, moving := range w.Balls {
+					if moving.Wait == 0 {
+						moving.Accelerate(w.Rules.SpeedIncrement * math.Sqrt2)
+					}
+				}
+				w.Player1.Speed += w.Rules.SpeedIncrement
+				w.Player2.Speed += w.Rules.SpeedIncrement
+			}
+		}
+
+		if ball.X < 0 {
+			w.score(2)
+			events.Player2.Points++
+			w.serve(ball, BallInterval)
+		} else if ball.X > float32(w.Width) {
+			w.score(1)
+			events.Player1.Points++
+			w.serve(ball, BallInterval)
+		}
+	}
+
+	for i, ball := range w.Balls {
+		for _, other := range w.Balls[i+1:] {
+			if ball.Wait == 0 && other.Wait == 0 {
+				ball.Collide(other)
+			}
+		}
+	}
+	if events != (Events{}) {
+		w.Telemetry.Record(Entry{
+			Kind: GameKind,
+			Value: GameEvents{
+				Tick:   w.Ticks,
+				Events: events,
+				Rally:  w.Rally,
+				Score:  [2]int{w.Player1.Score, w.Player2.Score},
+			},
+		})
+	}
+	// a point ends the rally, in crazy mode the other balls keep going
+	if events.Player1.Points+events.Player2.Points > 0 {
+		w.resetRally()
+	}
+	return events
+}
+
+// Episode is the outcome of a point played by two controllers
+type Episode struct {
+	// Ticks is the number of ticks played
+	Ticks int
+	// Rally is the number of hits before the point
+	Rally int
+	// Winner is the player that won the point, 0 if the point wasn't over after the maximum number of ticks
+	Winner  int
+	Player1 PlayerEvents
+	Player2 PlayerEvents
+}
+
+// Tick plays one tick between two controllers, the frame is rendered for the controllers when render is true,
+// the controllers that learn are rewarded for their hits and misses
+func (w *World) Tick(player1, player2 Controller, render bool) Events {
+	observation1, observation2 := w.Observe(1), w.Observe(2)
+	if render {
+		w.frame = w.Render(w.frame)
+		observation1.Frame, observation2.Frame = w.frame, w.frame
+	}
+	events := w.Step(Inputs{
+		Player1: player1.Act(observation1),
+		Player2: player2.Act(observation2),
+	})
+	Reward(events, player1, player2)
+	return events
+}
+
+// Play resets the world and the controllers and plays a point between two controllers for at most maxTicks ticks,
+// the frames are rendered for the controllers when render is true
+func (w *World) Play(player1, player2 Controller, maxTicks int, render bool) Episode {
+	w.Reset()
+	ResetControllers(player1, player2)
+	episode := Episode{}
+	for episode.Ticks < maxTicks && episode.Winner == 0 {
+		events := w.Tick(player1, player2, render)
+		episode.Ticks++
+		episode.Player1.Hits += events.Player1.Hits
+		episode.Player2.Hits += events.Player2.Hits
+		episode.Player1.Points += events.Player1.Points
+		episode.Player2.Points += events.Player2.Points
+		if events.Player1.Points > 0 {
+			episode.Winner = 1
+		} else if events.Player2.Points > 0 {
+			episode.Winner = 2
+		}
+	}
+	// the rally is over once the point is scored, so it is counted from the hits
+	episode.Rally = episode.Player1.Hits + episode.Player2.Hits
+	return episode
+}
+
+// Match restarts the world, resets the controllers and plays a match between two controllers for at most maxTicks ticks,
+// it returns the winner, or the leader when the ticks run out, 0 for a tie
+func (w *World) Match(player1, player2 Controller, maxTicks int, render bool) int {
+	w.Restart()
+	ResetControllers(player1, player2)
+	for w.Winner() == 0 && w.Ticks < maxTicks {
+		events := w.Tick(player1, player2, render)
+		// like in the game, the paddles and the ball go back to their places after each point,
+		// in crazy mode the balls are served again on their own
+		if events.Player1.Points+events.Player2.Points > 0 && len(w.Balls) == 1 {
+			w.Reset()
+			ResetControllers(player1, player2)
+		}
+	}
+	if winner := w.Winner(); winner != 0 {
+		return winner
+	}
+	if w.Player1.Score > w.Player2.Score {
+		return 1
+	} else if w.Player2.Score > w.Player1.Score {
+		return 2
+	}
+	return 0
+}
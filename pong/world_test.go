@@ -0,0 +1,143 @@
+package pong
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewWorldRules(t *testing.T) {
+	_, err := NewWorld(800, 600, Rules{MaxScore: 11, Serve: ServeWinner, BallVelocity: 4, PaddleSpeed: 6}, 1)
+	if err == nil {
+		t.Fatal("a world was created without the hits between speed ups")
+	}
+	_, err = NewWorld(800, 600, DefaultRules(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// matchResult is what a match ends with
+type matchResult struct {
+	Winner         int
+	Score1, Score2 int
+	Ticks          int
+}
+
+// players creates the controllers of a pairing, the AIs are seeded from seed
+type players func(seed int64) (Controller, Controller)
+
+var pairings = []struct {
+	name    string
+	players players
+}{
+	{"tracker vs medium", func(seed int64) (Controller, Controller) { return Tracker{}, NewAI(Medium, seed) }},
+	{"easy vs hard", func(seed int64) (Controller, Controller) { return NewAI(Easy, seed), NewAI(Hard, seed+1) }},
+	{"perfect vs tracker", func(seed int64) (Controller, Controller) { return NewAI(Perfect, seed), Tracker{} }},
+	{"idle vs medium", func(seed int64) (Controller, Controller) { return &Script{}, NewAI(Medium, seed) }},
+}
+
+// playMatch plays a match of the pairing in a world seeded with seed and checks that the result is consistent
+func playMatch(t *testing.T, rules Rules, balls int, p players, seed int64) matchResult {
+	t.Helper()
+	w, err := NewWorld(800, 600, rules, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetBalls(balls)
+	player1, player2 := p(seed)
+	maxTicks := 10 * 60 * TPS
+	winner := w.Match(player1, player2, maxTicks, false)
+	result := matchResult{winner, w.Player1.Score, w.Player2.Score, w.Ticks}
+
+	if result.Score1 < 0 || result.Score2 < 0 {
+		t.Fatalf("negative score: %+v", result)
+	}
+	if w.Winner() != 0 && w.Winner() != winner {
+		t.Fatalf("the match was won by %d but the world says %d: %+v", winner, w.Winner(), result)
+	}
+	if w.Winner() == 0 && w.Ticks < maxTicks {
+		t.Fatalf("the match stopped without a winner before the ticks ran out: %+v", result)
+	}
+	switch {
+	case result.Score1 > result.Score2 && winner != 1,
+		result.Score2 > result.Score1 && winner != 2,
+		result.Score1 == result.Score2 && winner != 0:
+		t.Fatalf("the winner doesn't match the score: %+v", result)
+	}
+	if w.Winner() != 0 && max(result.Score1, result.Score2) < rules.MaxScore {
+		t.Fatalf("the match was won below the max score: %+v", result)
+	}
+	return result
+}
+
+func TestMatch(t *testing.T) {
+	matches := 25
+	if testing.Short() {
+		matches = 5
+	}
+	rules := DefaultRules()
+	rules.MaxScore = 5
+	crazy := rules
+	crazy.WinByTwo = true
+	for _, pairing := range pairings {
+		for _, mode := range []struct {
+			name  string
+			rules Rules
+			balls int
+		}{
+			{"one ball", rules, 1},
+			{"crazy", crazy, CrazyBalls},
+		} {
+			t.Run(fmt.Sprintf("%s %s", pairing.name, mode.name), func(t *testing.T) {
+				for seed := range int64(matches) {
+					result := playMatch(t, mode.rules, mode.balls, pairing.players, seed)
+					again := playMatch(t, mode.rules, mode.balls, pairing.players, seed)
+					if again != result {
+						t.Fatalf("seed %d: the same match ended with %+v and then %+v", seed, result, again)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestPlay(t *testing.T) {
+	episodes := 200
+	if testing.Short() {
+		episodes = 20
+	}
+	for _, pairing := range pairings {
+		t.Run(pairing.name, func(t *testing.T) {
+			play := func() []Episode {
+				w, err := NewWorld(800, 600, DefaultRules(), 3)
+				if err != nil {
+					t.Fatal(err)
+				}
+				player1, player2 := pairing.players(3)
+				played := make([]Episode, episodes)
+				for i := range played {
+					played[i] = w.Play(player1, player2, 60*TPS, false)
+				}
+				return played
+			}
+			played := play()
+			for i, episode := range played {
+				switch {
+				case episode.Winner == 1 && (episode.Player1.Points != 1 || episode.Player2.Points != 0),
+					episode.Winner == 2 && (episode.Player2.Points != 1 || episode.Player1.Points != 0),
+					episode.Winner == 0 && episode.Player1.Points+episode.Player2.Points != 0:
+					t.Fatalf("episode %d: the winner doesn't match the points: %+v", i, episode)
+				}
+				if episode.Rally != episode.Player1.Hits+episode.Player2.Hits {
+					t.Fatalf("episode %d: the rally isn't the number of hits: %+v", i, episode)
+				}
+			}
+			again := play()
+			for i := range played {
+				if again[i] != played[i] {
+					t.Fatalf("episode %d: the same episode was %+v and then %+v", i, played[i], again[i])
+				}
+			}
+		})
+	}
+}
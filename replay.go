@@ -0,0 +1,171 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/dstoiko/go-pong-wasm/pong"
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// seekStep is how many ticks the arrow keys seek during playback
+const seekStep = 5 * 60
+
+// startRecording starts recording a new match if recording is enabled
+func (g *Game) startRecording() {
+	if g.output == "" || g.replay != nil {
+		return
+	}
+	g.matches++
+	g.record = &pong.Replay{
+		AiMode:      g.aiMode,
+		Balls:       len(g.world.Balls),
+		Rules:       g.world.Rules,
+		GameSeed:    g.seed,
+		NetworkSeed: g.netSeed,
+		Width:       g.world.Width,
+		Height:      g.world.Height,
+	}
+}
+
+// replayName returns the name of the replay file of the match-th match of the session:
+// the first match is saved to the name given with -record and the next ones are numbered
+func replayName(name string, match int) string {
+	if match <= 1 {
+		return name
+	}
+	extension := filepath.Ext(name)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, extension), match, extension)
+}
+
+// save writes the match being recorded to its replay file
+func (g *Game) save() {
+	if g.record == nil {
+		return
+	}
+	record := g.record
+	g.record = nil
+	output, err := os.Create(replayName(g.output, g.matches))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer output.Close()
+	err = record.Write(output)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// playback advances the replay by the playback speed
+func (g *Game) playback() {
+	for range g.speed {
+		if g.tick >= len(g.replay.Inputs) {
+			g.state = pong.GameOverState
+			return
+		}
+		g.play(g.replay.Inputs[g.tick])
+		g.tick++
+		if g.state == pong.InterState {
+			g.state = pong.PlayState
+		}
+		if g.state != pong.PlayState {
+			return
+		}
+	}
+}
+
+// seek restarts the replay and fast forwards it to tick
+func (g *Game) seek(tick int) {
+	g.reset(pong.StartState)
+	g.state = pong.PlayState
+	g.tick = 0
+	speed := g.speed
+	g.speed = 1
+	for g.tick < tick && g.state == pong.PlayState {
+		g.playback()
+	}
+	g.speed = speed
+}
+
+// replayControls handles the playback speed and seeking keys
+func (g *Game) replayControls() {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.Key1):
+		g.speed = 1
+	case inpututil.IsKeyJustPressed(ebiten.Key2):
+		g.speed = 2
+	case inpututil.IsKeyJustPressed(ebiten.Key4):
+		g.speed = 4
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		g.seekPaused(g.tick - seekStep)
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight):
+		g.seekPaused(g.tick + seekStep)
+	}
+}
+
+// seekPaused seeks while keeping the playback paused if it was
+func (g *Game) seekPaused(tick int) {
+	state := g.state
+	g.seek(max(tick, 0))
+	if state == pong.PauseState && g.state == pong.PlayState {
+		g.state = pong.PauseState
+	}
+}
+
+// NewReplayGame creates a game that plays back a replay
+func NewReplayGame(replay *pong.Replay, speed int) *Game {
+	g := NewGame(replay.AiMode, &replay.Rules, pong.PixelPerception, replay.GameSeed, replay.NetworkSeed)
+	world, err := pong.NewWorld(replay.Width, replay.Height, replay.Rules, replay.GameSeed)
+	if err != nil {
+		panic(err)
+	}
+	g.world = world
+	g.rng = g.world.Rng
+	g.world.SetBalls(replay.Balls)
+	g.ball = NewBallSprite(g.world.Balls[0])
+	g.player1 = NewPaddleSprite(g.world.Player1)
+	g.player2 = NewPaddleSprite(g.world.Player2)
+	g.replay = replay
+	g.speed = speed
+	return g
+}
+
+// runReplay implements the replay command
+func runReplay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := flags.Int("speed", 1, "playback speed: 1, 2 or 4")
+	seek := flags.Int("seek", 0, "tick to start the playback at")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: pong replay [flags] <file>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *speed != 1 && *speed != 2 && *speed != 4 {
+		log.Fatalf("invalid playback speed: %d", *speed)
+	}
+
+	input, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	replay, err := pong.ReadReplay(input)
+	input.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := NewReplayGame(replay, *speed)
+	g.seek(*seek)
+	if err := ebiten.RunGame(g); err != nil {
+		panic(err)
+	}
+}
@@ -1,6 +1,8 @@
-package pong
+package main
 
 import (
+	"fmt"
+	"github.com/dstoiko/go-pong-wasm/pong"
 	"github.com/golang/freetype/truetype"
 	"github.com/hajimehoshi/ebiten"
 	"github.com/hajimehoshi/ebiten/examples/resources/fonts"
@@ -8,6 +10,7 @@ import (
 	"golang.org/x/image/font"
 	"image/color"
 	"log"
+	"strconv"
 )
 
 const (
@@ -38,13 +41,13 @@ func InitFonts() {
 	})
 }
 
-func DrawCaption(state GameState, color color.Color, screen *ebiten.Image) {
+func DrawCaption(state pong.GameState, color color.Color, screen *ebiten.Image) {
 	w, h := screen.Size()
 	msg := []string{}
 	switch state {
-	case PlayState, InterState, PauseState:
+	case pong.PlayState, pong.InterState, pong.PauseState:
 		msg = append(msg, "Press SPACE key to take a break (not too long though)")
-	case ControlsState:
+	case pong.ControlsState:
 		msg = append(msg, "Press SPACE to go back to main menu")
 	}
 	for i, l := range msg {
@@ -53,11 +56,11 @@ func DrawCaption(state GameState, color color.Color, screen *ebiten.Image) {
 	}
 }
 
-func DrawBigText(state GameState, color color.Color, screen *ebiten.Image) {
+func DrawBigText(state pong.GameState, difficulty pong.Difficulty, color color.Color, screen *ebiten.Image) {
 	w, _ := screen.Size()
 	var texts []string
 	switch state {
-	case StartState:
+	case pong.StartState:
 		texts = []string{
 			"",
 			"PONG",
@@ -65,8 +68,10 @@ func DrawBigText(state GameState, color color.Color, screen *ebiten.Image) {
 			"C -> CONTROLS",
 			"V -> VS GAME",
 			"A -> AI GAME",
+			"D -> AI " + difficulty.String(),
+			"X -> CRAZY GAME",
 		}
-	case ControlsState:
+	case pong.ControlsState:
 		texts = []string{
 			"",
 			"PLAYER 1:",
@@ -77,14 +82,14 @@ func DrawBigText(state GameState, color color.Color, screen *ebiten.Image) {
 			"O -> UP",
 			"K -> DOWN",
 		}
-	case InterState:
+	case pong.InterState:
 		texts = []string{
 			"",
 			"",
 			"SPACE -> RESUME",
 			"R     -> RESET",
 		}
-	case PauseState:
+	case pong.PauseState:
 		texts = []string{
 			"",
 			"PAUSED",
@@ -92,7 +97,7 @@ func DrawBigText(state GameState, color color.Color, screen *ebiten.Image) {
 			"SPACE -> RESUME",
 			"R     -> RESET",
 		}
-	case GameOverState:
+	case pong.GameOverState:
 		texts = []string{
 			"",
 			"GAME OVER!",
@@ -104,3 +109,26 @@ func DrawBigText(state GameState, color color.Color, screen *ebiten.Image) {
 		text.Draw(screen, l, ArcadeFont, x, (i+4)*fontSize, color)
 	}
 }
+
+// DrawPlayers draws the controllers picked for each player under the start menu
+func DrawPlayers(names [2]string, color color.Color, screen *ebiten.Image) {
+	w, _ := screen.Size()
+	for i, name := range names {
+		l := fmt.Sprintf("%d -> P%d %s", i+1, i+1, name)
+		x := (w - len(l)*fontSize) / 2
+		text.Draw(screen, l, ArcadeFont, x, (i+12)*fontSize, color)
+	}
+}
+
+// DrawCountdown draws the seconds left before each served ball moves
+func DrawCountdown(world *pong.World, color color.Color, screen *ebiten.Image) {
+	for _, ball := range world.Balls {
+		if ball.Wait <= 0 || ball.Wait > world.Rules.Countdown*pong.TPS {
+			continue
+		}
+		l := strconv.Itoa((ball.Wait + pong.TPS - 1) / pong.TPS)
+		x := int(ball.X) - len(l)*fontSize/2
+		y := int(ball.Y - ball.Radius - fontSize)
+		text.Draw(screen, l, ArcadeFont, x, y, color)
+	}
+}